@@ -0,0 +1,339 @@
+package deej
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// apiServer exposes deej's slider activity over HTTP for external tooling
+type apiServer struct {
+	deej   *Deej
+	logger *zap.SugaredLogger
+
+	server *http.Server
+
+	eventClients map[chan sliderMoveEventJSON]bool
+	lock         sync.Locker
+}
+
+// sliderMoveEventJSON is the wire representation of a SliderMoveEvent
+type sliderMoveEventJSON struct {
+	SliderID     int       `json:"sliderId"`
+	PercentValue float32   `json:"percentValue"`
+	Preview      bool      `json:"preview"`
+	Bipolar      bool      `json:"bipolar"`
+	Targets      []string  `json:"targets,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// sliderJSON is the wire representation of a single slider's state. like in move events,
+// bipolar sliders carry a value between -1.0 and 1.0
+type sliderJSON struct {
+	SliderID     int      `json:"sliderId"`
+	PercentValue float32  `json:"percentValue"`
+	Bipolar      bool     `json:"bipolar"`
+	Targets      []string `json:"targets,omitempty"`
+}
+
+// setSliderRequestJSON is the body of a request to move a slider
+type setSliderRequestJSON struct {
+	PercentValue *float32 `json:"percentValue"`
+	Preview      bool     `json:"preview"`
+}
+
+const (
+
+	// how many events a slow event stream client can fall behind before we start dropping events for it
+	eventClientBufferSize = 64
+
+	apiServerShutdownTimeout = 2 * time.Second
+
+	apiSlidersPrefix = "/sliders/"
+
+	// largest request body we're willing to read, which is plenty for any of ours
+	apiMaxRequestBodySize = 4096
+)
+
+func newAPIServer(deej *Deej, logger *zap.SugaredLogger) (*apiServer, error) {
+	logger = logger.Named("api")
+
+	s := &apiServer{
+		deej:         deej,
+		logger:       logger,
+		eventClients: make(map[chan sliderMoveEventJSON]bool),
+		lock:         &sync.Mutex{},
+	}
+
+	logger.Debug("Created API server instance")
+
+	return s, nil
+}
+
+func (s *apiServer) start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/sliders", s.handleSliders)
+	mux.HandleFunc(apiSlidersPrefix, s.handleSlider)
+
+	s.server = &http.Server{
+		Addr:    s.deej.config.APIServer.Address,
+		Handler: mux,
+	}
+
+	// bind synchronously so that a bad or busy address is reported to the caller
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		s.logger.Warnw("Failed to listen on API server address", "address", s.server.Addr, "error", err)
+		return fmt.Errorf("listen on API server address: %w", err)
+	}
+
+	// a single subscription fans out to all connected clients, since serial consumers must always be drained
+	s.setupOnSliderMove()
+
+	s.logger.Infow("Serving API", "address", listener.Addr())
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Warnw("API server stopped unexpectedly", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *apiServer) stop() error {
+	if s.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiServerShutdownTimeout)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Warnw("Failed to shut down API server", "error", err)
+		return fmt.Errorf("shut down API server: %w", err)
+	}
+
+	s.logger.Debug("API server stopped")
+
+	return nil
+}
+
+func (s *apiServer) setupOnSliderMove() {
+	sliderEventsChannel := s.deej.serial.SubscribeToSliderMoveEvents()
+
+	go func() {
+		for {
+			select {
+			case event := <-sliderEventsChannel:
+				targets, _ := s.deej.config.SliderMapping.get(event.SliderID)
+
+				s.broadcast(sliderMoveEventJSON{
+					SliderID:     event.SliderID,
+					PercentValue: event.PercentValue,
+					Preview:      event.Preview,
+					Bipolar:      event.Bipolar,
+					Targets:      targets,
+					Timestamp:    time.Now(),
+				})
+			}
+		}
+	}()
+}
+
+func (s *apiServer) broadcast(event sliderMoveEventJSON) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for client := range s.eventClients {
+
+		// never let a slow client hold up the serial read loop
+		select {
+		case client <- event:
+		default:
+			s.logger.Debugw("Event stream client is falling behind, dropping event", "event", event)
+		}
+	}
+}
+
+// handleEvents streams slider move events to the client as server-sent events
+func (s *apiServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	client := make(chan sliderMoveEventJSON, eventClientBufferSize)
+
+	s.lock.Lock()
+	s.eventClients[client] = true
+	s.lock.Unlock()
+
+	s.logger.Debugw("Event stream client connected", "remoteAddr", r.RemoteAddr)
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.eventClients, client)
+		s.lock.Unlock()
+
+		s.logger.Debugw("Event stream client disconnected", "remoteAddr", r.RemoteAddr)
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-client:
+			payload, err := json.Marshal(event)
+			if err != nil {
+				s.logger.Warnw("Failed to marshal slider move event", "error", err, "event", event)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// handleSliders returns the current value of every slider that has reported one
+func (s *apiServer) handleSliders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sliders := []sliderJSON{}
+
+	for sliderID, value := range s.deej.serial.snapshotValues() {
+
+		// this slider hasn't reported a value since the last resync
+		if value < 0 {
+			continue
+		}
+
+		sliders = append(sliders, s.describeSlider(sliderID, value))
+	}
+
+	s.writeJSON(w, sliders)
+}
+
+// describeSlider describes the given slider at the given (stored, 0.0 - 1.0) value
+func (s *apiServer) describeSlider(sliderID int, value float32) sliderJSON {
+	targets, _ := s.deej.config.SliderMapping.get(sliderID)
+
+	result := sliderJSON{
+		SliderID:     sliderID,
+		PercentValue: value,
+		Targets:      targets,
+	}
+
+	// same mapping as their move events get
+	if s.deej.serial.sliderBipolar(sliderID) {
+		result.PercentValue = value*2 - 1
+		result.Bipolar = true
+	}
+
+	return result
+}
+
+// handleSlider returns (GET) or sets (POST) a single slider's value. setting a value moves the slider
+// as if it was moved by hand, so it's applied to its targets and seen by every other consumer. like in
+// move events, bipolar sliders take a value between -1.0 and 1.0
+func (s *apiServer) handleSlider(w http.ResponseWriter, r *http.Request) {
+	sliderID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, apiSlidersPrefix))
+	if err != nil || sliderID < 0 {
+		http.Error(w, "invalid slider ID", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		value, ok := s.deej.serial.CurrentValue(sliderID)
+		if !ok {
+			http.Error(w, "unknown slider", http.StatusNotFound)
+			return
+		}
+
+		s.writeJSON(w, s.describeSlider(sliderID, value))
+	case http.MethodPost:
+		if !s.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var request setSliderRequestJSON
+
+		decoder := json.NewDecoder(io.LimitReader(r.Body, apiMaxRequestBodySize))
+		if err := decoder.Decode(&request); err != nil || request.PercentValue == nil {
+			http.Error(w, "expected a JSON body with a percentValue", http.StatusBadRequest)
+			return
+		}
+
+		s.logger.Debugw("Setting slider value over API",
+			"sliderID", sliderID,
+			"value", *request.PercentValue,
+			"remoteAddr", r.RemoteAddr)
+
+		// values are validated against the slider's own range, and locked or disabled sliders stay put
+		if err := s.deej.serial.SetSliderValue(sliderID, *request.PercentValue, request.Preview); err != nil {
+			status := http.StatusConflict
+			if errors.Is(err, ErrSliderValueOutOfRange) {
+				status = http.StatusBadRequest
+			}
+
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorized checks the request's bearer token against the configured one, if there is one
+func (s *apiServer) authorized(r *http.Request) bool {
+	token := s.deej.config.APIServer.Token
+	if token == "" {
+		return true
+	}
+
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+func (s *apiServer) writeJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		s.logger.Warnw("Failed to write API response", "error", err)
+	}
+}
@@ -0,0 +1,220 @@
+package deej
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIServerHandleSlider(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     CanonicalConfig
+		apiToken   string
+		locked     bool
+		method     string
+		token      string
+		body       string
+		wantStatus int
+		wantValue  float32
+	}{
+		{
+			name:       "get",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantValue:  0.5,
+		},
+		{
+			name:       "get bipolar",
+			config:     CanonicalConfig{BipolarSliders: []int{0}},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantValue:  0,
+		},
+		{
+			name:       "post",
+			method:     http.MethodPost,
+			body:       `{"percentValue": 0.75}`,
+			wantStatus: http.StatusNoContent,
+			wantValue:  0.75,
+		},
+		{
+			name:       "post below 0",
+			method:     http.MethodPost,
+			body:       `{"percentValue": -0.5}`,
+			wantStatus: http.StatusBadRequest,
+			wantValue:  0.5,
+		},
+		{
+			name:       "post below 0 to a bipolar slider",
+			config:     CanonicalConfig{BipolarSliders: []int{0}},
+			method:     http.MethodPost,
+			body:       `{"percentValue": -0.5}`,
+			wantStatus: http.StatusNoContent,
+			wantValue:  0.25,
+		},
+		{
+			name:       "post to a locked slider",
+			locked:     true,
+			method:     http.MethodPost,
+			body:       `{"percentValue": 0.75}`,
+			wantStatus: http.StatusConflict,
+			wantValue:  0.5,
+		},
+		{
+			name:       "post without a value",
+			method:     http.MethodPost,
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantValue:  0.5,
+		},
+		{
+			name:       "post with the right token",
+			apiToken:   "secret",
+			method:     http.MethodPost,
+			token:      "secret",
+			body:       `{"percentValue": 0.75}`,
+			wantStatus: http.StatusNoContent,
+			wantValue:  0.75,
+		},
+		{
+			name:       "post with the wrong token",
+			apiToken:   "secret",
+			method:     http.MethodPost,
+			token:      "guess",
+			body:       `{"percentValue": 0.75}`,
+			wantStatus: http.StatusUnauthorized,
+			wantValue:  0.5,
+		},
+		{
+			name:       "post without a token",
+			apiToken:   "secret",
+			method:     http.MethodPost,
+			body:       `{"percentValue": 0.75}`,
+			wantStatus: http.StatusUnauthorized,
+			wantValue:  0.5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.SliderMapping = newSliderMap()
+			config.APIServer.Token = test.apiToken
+
+			sio := newTestSerialIO(t, &config)
+			s, _ := newAPIServer(sio.deej, sio.logger)
+
+			sio.valuesLock.Lock()
+			sio.resetSliders(1)
+			sio.currentSliderPercentValues[0] = 0.5
+			sio.valuesLock.Unlock()
+
+			sio.SetSliderLocked(0, test.locked)
+
+			request := httptest.NewRequest(test.method, apiSlidersPrefix+"0", strings.NewReader(test.body))
+			if test.token != "" {
+				request.Header.Set("Authorization", "Bearer "+test.token)
+			}
+
+			recorder := httptest.NewRecorder()
+			s.handleSlider(recorder, request)
+
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("got status %d, want %d (%s)", recorder.Code, test.wantStatus, recorder.Body)
+			}
+
+			if test.method == http.MethodGet {
+				var slider sliderJSON
+				if err := json.NewDecoder(recorder.Body).Decode(&slider); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+
+				if slider.PercentValue != test.wantValue {
+					t.Errorf("got value %v, want %v", slider.PercentValue, test.wantValue)
+				}
+
+				return
+			}
+
+			// the stored value is always between 0.0 and 1.0
+			if stored, _ := sio.CurrentValue(0); stored != test.wantValue {
+				t.Errorf("stored %v, want %v", stored, test.wantValue)
+			}
+		})
+	}
+}
+
+func TestAPIServerStreamsEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    CanonicalConfig
+		line      string
+		wantEvent sliderMoveEventJSON
+	}{
+		{
+			name:      "slider move",
+			line:      "512\r\n",
+			wantEvent: sliderMoveEventJSON{SliderID: 0, PercentValue: 0.5},
+		},
+		{
+			name:      "bipolar slider move",
+			config:    CanonicalConfig{BipolarSliders: []int{0}},
+			line:      "1023\r\n",
+			wantEvent: sliderMoveEventJSON{SliderID: 0, PercentValue: 1, Bipolar: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.SliderMapping = newSliderMap()
+
+			sio := newTestSerialIO(t, &config)
+			s, _ := newAPIServer(sio.deej, sio.logger)
+			s.setupOnSliderMove()
+
+			server := httptest.NewServer(http.HandlerFunc(s.handleEvents))
+			defer server.Close()
+
+			response, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("connect to event stream: %v", err)
+			}
+			defer response.Body.Close()
+
+			if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+				t.Fatalf("got content type %q, want text/event-stream", contentType)
+			}
+
+			// headers go out before the client is registered, so wait for that before moving anything
+			for registered := false; !registered; time.Sleep(time.Millisecond) {
+				s.lock.Lock()
+				registered = len(s.eventClients) == 1
+				s.lock.Unlock()
+			}
+
+			sio.handleLine(sio.logger, test.line, newLineFormat("|", "\n", checksumNone))
+
+			reader := bufio.NewReader(response.Body)
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read event: %v", err)
+			}
+
+			var event sliderMoveEventJSON
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				t.Fatalf("decode event %q: %v", line, err)
+			}
+
+			if event.SliderID != test.wantEvent.SliderID || event.PercentValue != test.wantEvent.PercentValue ||
+				event.Bipolar != test.wantEvent.Bipolar {
+				t.Errorf("got event %+v, want %+v", event, test.wantEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyResult reports the outcome of applying a slider move, i.e. setting the volume of its mapped sessions.
+// Err is nil if it was applied successfully
+type ApplyResult struct {
+	SliderID     int
+	PercentValue float32
+	Err          error
+}
+
+// errNoTargetSession means none of a slider's targets currently have any audio sessions (i.e. the app was closed)
+var errNoTargetSession = errors.New("no session found for any of the slider's targets")
+
+// placeholder in the apply failure feedback line, replaced with the failing slider's index
+const applyFeedbackSliderPlaceholder = "{slider}"
+
+// ReportApplyResult lets whoever applied a slider move tell deej how it went. Failures are logged and, if
+// configured, also written back to the board as a feedback line (i.e. so it can blink an LED)
+func (sio *SerialIO) ReportApplyResult(result ApplyResult) {
+	if result.Err == nil {
+		return
+	}
+
+	// a slider mapped to an app that isn't running fails on every move, so this stays at debug level
+	sio.logger.Debugw("Failed to apply slider move",
+		"sliderID", result.SliderID,
+		"percentValue", result.PercentValue,
+		"error", result.Err)
+
+	feedbackLine := sio.deej.config.ApplyFailureFeedback
+	if feedbackLine == "" {
+		return
+	}
+
+	feedbackLine = strings.ReplaceAll(feedbackLine, applyFeedbackSliderPlaceholder, strconv.Itoa(result.SliderID))
+
+	if err := sio.writeLine(feedbackLine); err != nil {
+		sio.logger.Warnw("Failed to write apply failure feedback to board", "line", feedbackLine, "error", err)
+	}
+}
+
+// writeLine sends the given line to the board, terminated with CRLF
+func (sio *SerialIO) writeLine(line string) error {
+
+	// the connection may be closed from under us once we let go of the lock, which just fails the write
+	sio.connectLock.Lock()
+	conn, connected := sio.conn, sio.connected
+	sio.connectLock.Unlock()
+
+	if !connected || conn == nil {
+		return errors.New("serial: not connected")
+	}
+
+	if _, err := conn.Write([]byte(line + "\r\n")); err != nil {
+		return fmt.Errorf("write line: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package deej
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReportApplyResult(t *testing.T) {
+	tests := []struct {
+		name         string
+		feedbackLine string
+		connected    bool
+		result       ApplyResult
+		want         string
+	}{
+		{
+			name:         "failure",
+			feedbackLine: "ERR {slider}",
+			connected:    true,
+			result:       ApplyResult{SliderID: 2, PercentValue: 0.5, Err: errNoTargetSession},
+			want:         "ERR 2\r\n",
+		},
+		{
+			name:         "success",
+			feedbackLine: "ERR {slider}",
+			connected:    true,
+			result:       ApplyResult{SliderID: 2, PercentValue: 0.5},
+		},
+		{
+			name:      "failure without feedback configured",
+			connected: true,
+			result:    ApplyResult{SliderID: 2, PercentValue: 0.5, Err: errors.New("oops")},
+		},
+		{
+			name:         "failure while disconnected",
+			feedbackLine: "ERR {slider}",
+			result:       ApplyResult{SliderID: 2, PercentValue: 0.5, Err: errNoTargetSession},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{ApplyFailureFeedback: test.feedbackLine})
+
+			conn := &testConn{Reader: strings.NewReader("")}
+			sio.conn = conn
+			sio.connected = test.connected
+
+			sio.ReportApplyResult(test.result)
+
+			if got := conn.writtenString(); got != test.want {
+				t.Errorf("wrote %q to the board, want %q", got, test.want)
+			}
+		})
+	}
+}
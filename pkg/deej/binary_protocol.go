@@ -0,0 +1,173 @@
+package deej
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// in binary mode, boards send one fixed-size frame per slider reading instead of text lines:
+//
+//	[0xAA][slider ID: 1 byte][value: 2 bytes][flags: 1 byte][checksum: 1 byte]
+//
+// the checksum is the XOR of the slider ID, value and flags bytes. flags are currently reserved
+const (
+	binaryFrameSync   = 0xAA
+	binaryFrameLength = 6
+
+	protocolText   = "text"
+	protocolBinary = "binary"
+
+	byteOrderBig    = "big"
+	byteOrderLittle = "little"
+)
+
+// binaryFrame is a single decoded slider reading from a binary mode board
+type binaryFrame struct {
+	SliderID int
+	Value    int
+	Flags    byte
+
+	raw []byte
+}
+
+func (sio *SerialIO) byteOrder() binary.ByteOrder {
+	if sio.deej.config.BinaryByteOrder == byteOrderLittle {
+		return binary.LittleEndian
+	}
+
+	return binary.BigEndian
+}
+
+// readFrames reads binary frames from the given reader, resynchronizing on the next sync byte
+// whenever a frame fails its checksum
+func (sio *SerialIO) readFrames(logger *zap.SugaredLogger, reader *bufio.Reader, errChannel chan<- error,
+	doneChannel <-chan bool) chan binaryFrame {
+
+	ch := make(chan binaryFrame)
+	byteOrder := sio.byteOrder()
+
+	go func() {
+		for {
+
+			// skip ahead to the next sync byte
+			syncByte, err := reader.ReadByte()
+			if err != nil {
+				if sio.verbose() || sio.deej.config.LogSerialLines {
+					logger.Warnw("Failed to read frame from serial", "error", err)
+				}
+
+				// the read loop will stop after this
+				errChannel <- err
+				return
+			}
+
+			if syncByte != binaryFrameSync {
+				continue
+			}
+
+			// look at the rest of the frame without consuming it, so we can resync from right
+			// after this sync byte in case it turns out to just be part of a corrupted frame
+			body, err := reader.Peek(binaryFrameLength - 1)
+			if err != nil {
+				if sio.verbose() || sio.deej.config.LogSerialLines {
+					logger.Warnw("Failed to read frame from serial", "error", err)
+				}
+
+				errChannel <- err
+				return
+			}
+
+			if checksum := body[0] ^ body[1] ^ body[2] ^ body[3]; checksum != body[4] {
+				if sio.verbose() || sio.deej.config.LogSerialLines {
+					logger.Warnw("Dropped frame with bad checksum, resynchronizing",
+						"frame", fmt.Sprintf("% x", append([]byte{syncByte}, body...)),
+						"expectedChecksum", checksum)
+				}
+
+				continue
+			}
+
+			frame := binaryFrame{
+				SliderID: int(body[0]),
+				Value:    int(byteOrder.Uint16(body[1:3])),
+				Flags:    body[3],
+				raw:      append([]byte{syncByte}, body...),
+			}
+
+			if _, err := reader.Discard(len(body)); err != nil {
+				errChannel <- err
+				return
+			}
+
+			if sio.verbose() {
+				logger.Debugw("Read new frame", "frame", frame)
+			}
+
+			// the read loop may have stopped without waiting for us
+			select {
+			case ch <- frame:
+			case <-doneChannel:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// handleFrame processes a single binary frame, and returns whether it was a valid one
+func (sio *SerialIO) handleFrame(logger *zap.SugaredLogger, frame binaryFrame) bool {
+	rawFrame := fmt.Sprintf("% x", frame.raw)
+
+	if frame.Value > sio.maxRawValue() {
+		sio.logger.Debugw("Got out of range frame from serial, ignoring", "frame", rawFrame)
+		sio.recordLine(logger, lineTraceRecord{Line: rawFrame, DropReason: lineTraceDropReasonOutOfRange})
+
+		return false
+	}
+
+	sio.valuesLock.Lock()
+
+	// sliders report individually in binary mode, so we learn about them one at a time.
+	// a zeroed slider count means a resync was requested, same as in text mode
+	if frame.SliderID >= len(sio.currentSliderPercentValues) || sio.lastKnownNumSliders == 0 {
+		numSliders := len(sio.currentSliderPercentValues)
+		if frame.SliderID >= numSliders {
+			numSliders = frame.SliderID + 1
+			logger.Infow("Detected sliders", "amount", numSliders)
+		}
+
+		sio.resetSliders(numSliders)
+	}
+
+	if sio.deej.config.RailWarningDelay > 0 {
+		sio.rails.observe(frame.SliderID, frame.Value, sio.maxRawValue(), sio.deej.config.RailWarningDelay)
+	}
+
+	moveEvent, moved := sio.applyRawValue(logger, frame.SliderID, frame.Value)
+
+	// same as in text mode, nothing moves while the board is starting up
+	if sio.startingUp() {
+		moved = false
+	}
+
+	sio.valuesLock.Unlock()
+
+	moveEvents := []SliderMoveEvent{}
+	if moved {
+		moveEvents = sio.enabledMoveEvents(logger, []SliderMoveEvent{moveEvent})
+	}
+
+	sio.recordLine(logger, lineTraceRecord{Line: rawFrame, Accepted: true, Events: moveEvents})
+
+	if len(moveEvents) > 0 {
+		sio.deliverMoveEvents(moveEvents)
+	}
+
+	sio.reportRailedSliders(logger)
+
+	return true
+}
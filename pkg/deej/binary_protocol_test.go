@@ -0,0 +1,187 @@
+package deej
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// encodeTestFrame builds a binary frame the way a board would send it
+func encodeTestFrame(sliderID byte, value uint16, flags byte, byteOrder binary.ByteOrder) []byte {
+	frame := make([]byte, binaryFrameLength)
+
+	frame[0] = binaryFrameSync
+	frame[1] = sliderID
+	byteOrder.PutUint16(frame[2:4], value)
+	frame[4] = flags
+	frame[5] = frame[1] ^ frame[2] ^ frame[3] ^ frame[4]
+
+	return frame
+}
+
+// decodeTestFrames runs the given bytes through readFrames, and returns every frame it read before running out
+func decodeTestFrames(t *testing.T, sio *SerialIO, input []byte) []binaryFrame {
+	t.Helper()
+
+	errChannel := make(chan error, 1)
+	frames := sio.readFrames(sio.logger, bufio.NewReader(bytes.NewReader(input)), errChannel, make(chan bool))
+
+	got := []binaryFrame{}
+	for {
+		select {
+		case frame := <-frames:
+
+			// raw bytes are only kept for logging
+			frame.raw = nil
+			got = append(got, frame)
+		case err := <-errChannel:
+			if err != io.EOF {
+				t.Fatalf("readFrames stopped with an unexpected error: %v", err)
+			}
+
+			return got
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for readFrames")
+		}
+	}
+}
+
+func TestReadFrames(t *testing.T) {
+	corrupted := encodeTestFrame(1, 512, 0, binary.BigEndian)
+	corrupted[5] ^= 0xFF
+
+	// a corrupted frame whose value happens to contain a sync byte, followed by what looks like
+	// the rest of a frame from there on - resynchronizing must start right after the first sync byte
+	misaligned := []byte{binaryFrameSync, 0x01, binaryFrameSync, 0x02, 0x00, 0x10, 0x00, 0x12}
+
+	concat := func(chunks ...[]byte) []byte {
+		return bytes.Join(chunks, nil)
+	}
+
+	tests := []struct {
+		name      string
+		byteOrder string
+		input     []byte
+		want      []binaryFrame
+	}{
+		{
+			name:      "big endian",
+			byteOrder: byteOrderBig,
+			input:     encodeTestFrame(0, 1023, 0, binary.BigEndian),
+			want:      []binaryFrame{{SliderID: 0, Value: 1023}},
+		},
+		{
+			name:      "little endian",
+			byteOrder: byteOrderLittle,
+			input:     encodeTestFrame(2, 300, 0, binary.LittleEndian),
+			want:      []binaryFrame{{SliderID: 2, Value: 300}},
+		},
+		{
+			name:      "flags",
+			byteOrder: byteOrderBig,
+			input:     encodeTestFrame(1, 42, 0x5A, binary.BigEndian),
+			want:      []binaryFrame{{SliderID: 1, Value: 42, Flags: 0x5A}},
+		},
+		{
+			name:      "several frames",
+			byteOrder: byteOrderBig,
+			input: concat(
+				encodeTestFrame(0, 0, 0, binary.BigEndian),
+				encodeTestFrame(1, 512, 0, binary.BigEndian),
+				encodeTestFrame(2, 1023, 0, binary.BigEndian)),
+			want: []binaryFrame{{SliderID: 0, Value: 0}, {SliderID: 1, Value: 512}, {SliderID: 2, Value: 1023}},
+		},
+		{
+			name:      "garbage before the sync byte",
+			byteOrder: byteOrderBig,
+			input:     concat([]byte{0x01, 0x02, 0x03}, encodeTestFrame(0, 100, 0, binary.BigEndian)),
+			want:      []binaryFrame{{SliderID: 0, Value: 100}},
+		},
+		{
+			name:      "bad checksum is dropped",
+			byteOrder: byteOrderBig,
+			input:     concat(corrupted, encodeTestFrame(3, 700, 0, binary.BigEndian)),
+			want:      []binaryFrame{{SliderID: 3, Value: 700}},
+		},
+		{
+			name:      "resync within a corrupted frame",
+			byteOrder: byteOrderBig,
+			input:     misaligned,
+			want:      []binaryFrame{{SliderID: 2, Value: 16}},
+		},
+		{
+			name:      "truncated frame",
+			byteOrder: byteOrderBig,
+			input:     encodeTestFrame(0, 1023, 0, binary.BigEndian)[:4],
+			want:      []binaryFrame{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{BinaryByteOrder: test.byteOrder})
+
+			if got := decodeTestFrames(t, sio, test.input); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got frames %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandleFrame(t *testing.T) {
+	tests := []struct {
+		name         string
+		frame        binaryFrame
+		wantAccepted bool
+		wantEvents   []SliderMoveEvent
+	}{
+		{
+			name:         "in range",
+			frame:        binaryFrame{SliderID: 1, Value: 512},
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 1, PercentValue: 0.5}},
+		},
+		{
+			name:       "out of range",
+			frame:      binaryFrame{SliderID: 0, Value: 2000},
+			wantEvents: []SliderMoveEvent{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			events := sio.SubscribeToSliderMoveEvents()
+
+			var accepted bool
+			done := make(chan bool)
+
+			go func() {
+				accepted = sio.handleFrame(sio.logger, test.frame)
+				close(done)
+			}()
+
+			got := []SliderMoveEvent{}
+			for waiting := true; waiting; {
+				select {
+				case event := <-events:
+					got = append(got, event)
+				case <-done:
+					waiting = false
+				}
+			}
+
+			if accepted != test.wantAccepted {
+				t.Errorf("handleFrame returned %v, want %v", accepted, test.wantAccepted)
+			}
+
+			if !reflect.DeepEqual(got, test.wantEvents) {
+				t.Errorf("handleFrame delivered %+v, want %+v", got, test.wantEvents)
+			}
+		})
+	}
+}
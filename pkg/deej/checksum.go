@@ -0,0 +1,74 @@
+package deej
+
+import (
+	"strconv"
+	"strings"
+)
+
+// boards can optionally append a checksum of each line's values to guard against corruption
+// over long or noisy cables, i.e. "512|300|1023*3A" where 3A is the hex checksum of "512|300|1023"
+const (
+	checksumNone = "none"
+	checksumXOR  = "xor"
+	checksumCRC8 = "crc8"
+
+	checksumSeparator = "*"
+
+	// CRC-8/SMBUS, which is what most arduino CRC8 libraries default to
+	crc8Polynomial = 0x07
+)
+
+func xorChecksum(data []byte) byte {
+	var checksum byte
+	for _, b := range data {
+		checksum ^= b
+	}
+
+	return checksum
+}
+
+func crc8Checksum(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		crc ^= b
+
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ crc8Polynomial
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// verifyChecksum splits the given (trimmed) line into its payload and checksum, and returns
+// the payload along with whether the checksum matches it according to the given algorithm
+func verifyChecksum(line string, algorithm string) (string, bool) {
+	separatorIdx := strings.LastIndex(line, checksumSeparator)
+	if separatorIdx == -1 {
+		return line, false
+	}
+
+	payload := line[:separatorIdx]
+
+	expected, err := strconv.ParseUint(line[separatorIdx+1:], 16, 8)
+	if err != nil {
+		return payload, false
+	}
+
+	var actual byte
+
+	switch algorithm {
+	case checksumXOR:
+		actual = xorChecksum([]byte(payload))
+	case checksumCRC8:
+		actual = crc8Checksum([]byte(payload))
+	default:
+		return payload, true
+	}
+
+	return payload, actual == byte(expected)
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/omriharel/deej/pkg/deej"
 )
@@ -13,16 +15,28 @@ var (
 	buildType  string
 
 	verbose bool
+
+	testPort         string
+	testPortBaudRate uint
+	testPortDuration time.Duration
 )
 
 func init() {
 	flag.BoolVar(&verbose, "verbose", false, "show verbose logs (useful for debugging serial)")
 	flag.BoolVar(&verbose, "v", false, "shorthand for --verbose")
+	flag.StringVar(&testPort, "test-port", "", "read from the given serial port for a while, report what was read and exit")
+	flag.UintVar(&testPortBaudRate, "test-baud-rate", 9600, "baud rate to use with --test-port")
+	flag.DurationVar(&testPortDuration, "test-duration", 5*time.Second, "how long to read for with --test-port")
 	flag.Parse()
 }
 
 func main() {
 
+	// testing a port doesn't involve the rest of deej at all
+	if testPort != "" {
+		os.Exit(runPortTest())
+	}
+
 	// first we need a logger
 	logger, err := deej.NewLogger(buildType)
 	if err != nil {
@@ -64,3 +78,36 @@ func main() {
 		named.Fatalw("Failed to initialize deej", "error", err)
 	}
 }
+
+func runPortTest() int {
+	fmt.Printf("Reading from %s at %d baud for %s...\n", testPort, testPortBaudRate, testPortDuration)
+
+	result, err := deej.TestPort(testPort, testPortBaudRate, testPortDuration)
+	if err != nil {
+		fmt.Printf("Port test failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Lines read: %d, matching deej's protocol: %d\n", result.LinesRead, result.MatchedLines)
+	fmt.Printf("Sliders detected: %d\n", result.SliderCount)
+
+	if len(result.SampleLines) > 0 {
+		fmt.Println("Sample lines:")
+		for _, line := range result.SampleLines {
+			fmt.Printf("  %q\n", line)
+		}
+	}
+
+	// exit with an error if nothing made sense, so scripts can tell a working board from a silent or misconfigured one
+	if result.MatchedLines == 0 {
+		if result.LinesRead > 0 {
+			fmt.Println("None of the lines matched deej's protocol, check that --test-baud-rate matches your board's baud rate")
+		} else {
+			fmt.Println("Nothing was read, check that your board is connected and that --test-baud-rate matches its baud rate")
+		}
+
+		return 1
+	}
+
+	return 0
+}
@@ -2,7 +2,10 @@ package deej
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,14 +22,159 @@ type CanonicalConfig struct {
 	SliderMapping *sliderMap
 
 	ConnectionInfo struct {
-		COMPort  string
-		BaudRate int
+		COMPort        string
+		BaudRate       int
+		ReadBufferSize int
+
+		// negative means "use the platform default"
+		MinimumReadSize int
+
+		Parity string
+	}
+
+	APIServer struct {
+		Enabled bool
+		Address string
+
+		// if set, requests that change anything must carry it as a bearer token
+		Token string
+	}
+
+	LineTrace struct {
+		Path    string
+		MaxSize int64
+	}
+
+	// sends slider moves out as MIDI control changes, for sliders that have a controller number
+	MIDIOutput struct {
+		Device string
+
+		// 1 - 16, as MIDI software usually numbers them
+		Channel int
+
+		Controllers map[int]int
 	}
 
 	InvertSliders bool
 
+	// whether gamma and curves apply to the raw slider position (and are then inverted), rather than the inverted one
+	CurveBeforeInvert bool
+
+	LockedSliders []int
+
+	// if any sliders are explicitly enabled, all others are ignored. disabled sliders are always ignored
+	EnabledSliders  []int
+	DisabledSliders []int
+
+	// bipolar sliders report -1.0 to 1.0 (centered at 0) instead of a volume, i.e. for pan controls
+	BipolarSliders []int
+
+	// gamma exponents applied to specific sliders' values (1.0, the default, changes nothing)
+	SliderGamma map[int]float32
+
+	// the number of evenly spaced levels specific sliders snap to, for a stepped feel
+	SliderSteps map[int]int
+
+	// piecewise-linear position to volume mappings, for when a gamma curve isn't enough
+	SliderCurves map[int]sliderCurve
+
+	// per-slider correction applied to raw values, i.e. for pots that never quite reach either end
+	SliderGain   map[int]float32
+	SliderOffset map[int]float32
+
+	// per-slider time constants for asymmetric smoothing: attack applies when the value goes up, release when it goes down
+	SliderAttack  map[int]time.Duration
+	SliderRelease map[int]time.Duration
+
+	// per-slider overrides for the global noise reduction level and smoothing factor, for especially noisy pots
+	SliderDeadzone  map[int]float32
+	SliderSmoothing map[int]float32
+
+	SliderTriggers    map[int][]SliderTrigger
+	TriggerHysteresis float32
+
 	NoiseReductionLevel string
 
+	// values within this distance of either end snap to it, so sliders can always reach exactly 0% and 100%
+	RailDeadzone float32
+
+	CenterDetent struct {
+		Center float32
+		Width  float32
+	}
+
+	// how slider peaks behave for SubscribeToPeaks: held for a while, then decaying by DecayRate per second
+	Peaks struct {
+		Hold      time.Duration
+		DecayRate float32
+	}
+
+	Smoothing struct {
+		Factor          float32
+		BypassThreshold float32
+
+		// defaults for sliders without their own attack and release. zero means unset
+		Attack  time.Duration
+		Release time.Duration
+	}
+
+	LogSerialLines        bool
+	LineLogSampleRate     int
+	ThroughputLogInterval time.Duration
+
+	RawMode string
+
+	Checksum string
+
+	FieldSeparator string
+	LineTerminator string
+
+	Protocol        string
+	BinaryByteOrder string
+
+	IdleTimeout time.Duration
+
+	// how long a slider can report one of its extremes (and nothing in between) before we suspect it's faulty.
+	// 0 disables the check
+	RailWarningDelay time.Duration
+
+	// how often to check whether the board's been plugged in while it's not connected. 0 disables this
+	HotplugPollInterval time.Duration
+
+	// how long to ignore slider moves for after connecting
+	StartupDelay time.Duration
+
+	ReconnectPolicy ReconnectPolicy
+
+	// how many times to retry the first connection before giving up. 0 gives up right away, and -1 retries
+	// busy ports and failed first reads for as long as the reconnect policy allows. an explicit limit
+	// retries any failure, i.e. for a board that isn't plugged in yet
+	MaxStartupRetries int
+
+	AdvancedSerialOptions AdvancedSerialOptions
+
+	CoalesceSliderEvents bool
+	SliderEventTick      time.Duration
+
+	// when positive, every consumer gets its own delivery queue of this size, so slow ones don't hold up the rest
+	SliderEventQueueSize int
+
+	// when set, slider moves are only applied once a slider's been still for this long (as if it was let go of)
+	ApplyOnRelease time.Duration
+
+	// emit a move event for every reading, even if the value didn't change (i.e. for motorized faders)
+	NoDedup bool
+
+	MaxLineLength int
+
+	// written to the board whenever a slider move fails to apply, with {slider} replaced by the slider's index
+	ApplyFailureFeedback string
+
+	TestPatternRate int
+
+	// when set to a test pattern, deej simulates slider activity instead of connecting to the board
+	Simulate string
+
 	logger             *zap.SugaredLogger
 	notifier           Notifier
 	stopWatcherChannel chan bool
@@ -37,6 +185,37 @@ type CanonicalConfig struct {
 	internalConfig *viper.Viper
 }
 
+// ReconnectPolicy bundles the timing parameters deej uses when (re)establishing its serial connection
+type ReconnectPolicy struct {
+	StopDelay      time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// 0 means there's no limit on the number of attempts
+	MaxAttempts int
+
+	// the fraction (0.0 - 1.0) of each backoff that's randomized, to avoid retrying in lockstep
+	Jitter float64
+}
+
+// AdvancedSerialOptions holds the lesser-used serial port options, for boards and adapters that need them.
+// these are merged on top of the primary connection fields, which can't be overridden from here
+type AdvancedSerialOptions struct {
+	DataBits          uint
+	StopBits          uint
+	RTSCTSFlowControl bool
+
+	// 0 means reads don't time out between characters
+	InterCharacterTimeout time.Duration
+
+	RS485Enabled            bool
+	RS485RTSHighDuringSend  bool
+	RS485RTSHighAfterSend   bool
+	RS485RxDuringTx         bool
+	RS485DelayRTSBeforeSend time.Duration
+	RS485DelayRTSAfterSend  time.Duration
+}
+
 const (
 	userConfigFilepath     = "config.yaml"
 	internalConfigFilepath = "preferences.yaml"
@@ -48,14 +227,134 @@ const (
 
 	configType = "yaml"
 
-	configKeySliderMapping       = "slider_mapping"
-	configKeyInvertSliders       = "invert_sliders"
-	configKeyCOMPort             = "com_port"
-	configKeyBaudRate            = "baud_rate"
-	configKeyNoiseReductionLevel = "noise_reduction"
+	configKeySliderMapping        = "slider_mapping"
+	configKeyInvertSliders        = "invert_sliders"
+	configKeyLockedSliders        = "locked_sliders"
+	configKeyEnabledSliders       = "enabled_sliders"
+	configKeyDisabledSliders      = "disabled_sliders"
+	configKeyBipolarSliders       = "bipolar_sliders"
+	configKeySliderGamma          = "slider_gamma"
+	configKeySliderGain           = "slider_gain"
+	configKeySliderOffset         = "slider_offset"
+	configKeySliderCurves         = "slider_curves"
+	configKeySliderAttack         = "slider_attack"
+	configKeySliderRelease        = "slider_release"
+	configKeySliderDeadzone       = "slider_deadzone"
+	configKeySliderSteps          = "slider_steps"
+	configKeySliderSmoothing      = "slider_smoothing"
+	configKeySliderTriggers       = "slider_triggers"
+	configKeyTriggerHysteresis    = "trigger_hysteresis"
+	configKeyCOMPort              = "com_port"
+	configKeyBaudRate             = "baud_rate"
+	configKeyReadBufferSize       = "read_buffer_size"
+	configKeyMinimumReadSize      = "minimum_read_size"
+	configKeyParity               = "parity"
+	configKeyNoiseReductionLevel  = "noise_reduction"
+	configKeyRailDeadzone         = "rail_deadzone"
+	configKeyCurveBeforeInvert    = "curve_before_invert"
+	configKeyCenterDetent         = "center_detent"
+	configKeyCenterDetentWidth    = "center_detent_width"
+	configKeyPeakHold             = "peak_hold"
+	configKeyPeakDecayRate        = "peak_decay_rate"
+	configKeySmoothingFactor      = "smoothing_factor"
+	configKeySmoothingBypass      = "smoothing_bypass_threshold"
+	configKeyAttack               = "smoothing_attack"
+	configKeyRelease              = "smoothing_release"
+	configKeyLogSerialLines       = "log_serial_lines"
+	configKeyLineLogSampleRate    = "line_log_sample_rate"
+	configKeyThroughputInterval   = "throughput_log_interval"
+	configKeyRawMode              = "raw_mode"
+	configKeyChecksum             = "checksum"
+	configKeyFieldSeparator       = "field_separator"
+	configKeyLineTerminator       = "line_terminator"
+	configKeyProtocol             = "protocol"
+	configKeyBinaryByteOrder      = "binary_byte_order"
+	configKeyIdleTimeout          = "idle_timeout"
+	configKeyHotplugPollInterval  = "hotplug_poll_interval"
+	configKeyRailWarningDelay     = "rail_warning_delay"
+	configKeyStartupDelay         = "startup_delay"
+	configKeyCoalesceSliderEvents = "coalesce_slider_events"
+	configKeySliderEventTick      = "slider_event_tick"
+	configKeySliderEventQueueSize = "slider_event_queue_size"
+	configKeyApplyOnRelease       = "apply_on_release"
+	configKeyNoDedup              = "no_dedup"
+	configKeyReconnectStopDelay   = "reconnect_policy.stop_delay"
+	configKeyReconnectInitial     = "reconnect_policy.initial_backoff"
+	configKeyReconnectMax         = "reconnect_policy.max_backoff"
+	configKeyReconnectMaxAttempts = "reconnect_policy.max_attempts"
+	configKeyReconnectJitter      = "reconnect_policy.jitter"
+	configKeyMaxStartupRetries    = "max_startup_retries"
+	configKeyAdvancedSerial       = "advanced_serial_options"
+	configKeyAPIServerEnabled     = "api_server_enabled"
+	configKeyAPIServerAddress     = "api_server_address"
+	configKeyAPIServerToken       = "api_server_token"
+	configKeyLineTracePath        = "line_trace_path"
+	configKeyLineTraceMaxSize     = "line_trace_max_size"
+	configKeyMaxLineLength        = "max_line_length"
+	configKeyApplyFailureFeedback = "apply_failure_feedback"
+	configKeyTestPatternRate      = "test_pattern_rate"
+	configKeySimulate             = "simulate"
+	configKeyMIDIOutputDevice     = "midi_output_device"
+	configKeyMIDIOutputChannel    = "midi_output_channel"
+	configKeyMIDIControllers      = "midi_controllers"
+
+	// raw slider values are 10-bit ADC readings (0-1023) by default,
+	// but some boards do the conversion themselves and send percentages (0-100)
+	rawModeADC     = "adc"
+	rawModePercent = "percent"
 
 	defaultCOMPort  = "COM4"
 	defaultBaudRate = 9600
+
+	// matches bufio's own default reader size
+	defaultReadBufferSize = 4096
+
+	defaultFieldSeparator = "|"
+	defaultLineTerminator = "\n"
+
+	// a negative minimum read size leaves the choice to the platform
+	defaultMinimumReadSize = -1
+
+	defaultParity = parityNone
+
+	defaultDataBits = 8
+	defaultStopBits = 1
+
+	// a fired trigger re-arms once its slider moves back past the threshold by this much
+	defaultTriggerHysteresis = 0.05
+
+	// sliders within this distance of the center detent snap to it. 0 disables the detent
+	defaultCenterDetent      = 0.5
+	defaultCenterDetentWidth = 0.0
+
+	// peaks are held for a second, then lose half of the full range per second
+	defaultPeakHold      = time.Second
+	defaultPeakDecayRate = 0.5
+
+	// smoothing is off by default. when enabled, moves this large (or larger) skip it entirely
+	defaultSmoothingFactor          = 0.0
+	defaultSmoothingBypassThreshold = 0.1
+
+	defaultReconnectStopDelay      = 50 * time.Millisecond
+	defaultReconnectInitialBackoff = time.Second
+	defaultReconnectMaxBackoff     = 30 * time.Second
+	defaultReconnectMaxAttempts    = 0
+	defaultReconnectJitter         = 0.2
+
+	// a busy port (i.e. held by a serial monitor) is usually let go of shortly, so it's retried on the reconnect
+	// policy's backoff by default. that's capped at its max backoff, and bounded by its max attempts if it has them
+	defaultMaxStartupRetries = -1
+
+	// only listen locally unless explicitly told otherwise
+	defaultAPIServerAddress = "127.0.0.1:8976"
+
+	// 10MB per trace file, keeping one rotated file around
+	defaultLineTraceMaxSize = 10 * 1024 * 1024
+
+	defaultMaxLineLength   = 1024
+	defaultTestPatternRate = 20
+
+	defaultMIDIOutputChannel = 1
 )
 
 // has to be defined as a non-constant because we're using path.Join
@@ -87,8 +386,74 @@ func NewConfig(logger *zap.SugaredLogger, notifier Notifier) (*CanonicalConfig,
 
 	userConfig.SetDefault(configKeySliderMapping, map[string][]string{})
 	userConfig.SetDefault(configKeyInvertSliders, false)
+	userConfig.SetDefault(configKeyLockedSliders, []int{})
+	userConfig.SetDefault(configKeyEnabledSliders, []int{})
+	userConfig.SetDefault(configKeyDisabledSliders, []int{})
+	userConfig.SetDefault(configKeyBipolarSliders, []int{})
+	userConfig.SetDefault(configKeySliderGamma, map[string]float64{})
+	userConfig.SetDefault(configKeySliderGain, map[string]float64{})
+	userConfig.SetDefault(configKeySliderOffset, map[string]float64{})
+	userConfig.SetDefault(configKeySliderCurves, map[string][]string{})
+	userConfig.SetDefault(configKeySliderAttack, map[string]string{})
+	userConfig.SetDefault(configKeySliderRelease, map[string]string{})
+	userConfig.SetDefault(configKeySliderDeadzone, map[string]float64{})
+	userConfig.SetDefault(configKeySliderSteps, map[string]float64{})
+	userConfig.SetDefault(configKeySliderSmoothing, map[string]float64{})
+	userConfig.SetDefault(configKeySliderTriggers, map[string][]string{})
+	userConfig.SetDefault(configKeyTriggerHysteresis, defaultTriggerHysteresis)
 	userConfig.SetDefault(configKeyCOMPort, defaultCOMPort)
 	userConfig.SetDefault(configKeyBaudRate, defaultBaudRate)
+	userConfig.SetDefault(configKeyReadBufferSize, defaultReadBufferSize)
+	userConfig.SetDefault(configKeyMinimumReadSize, defaultMinimumReadSize)
+	userConfig.SetDefault(configKeyParity, defaultParity)
+	userConfig.SetDefault(configKeyRailDeadzone, 0)
+	userConfig.SetDefault(configKeyCurveBeforeInvert, false)
+	userConfig.SetDefault(configKeyCenterDetent, defaultCenterDetent)
+	userConfig.SetDefault(configKeyCenterDetentWidth, defaultCenterDetentWidth)
+	userConfig.SetDefault(configKeyPeakHold, defaultPeakHold)
+	userConfig.SetDefault(configKeyPeakDecayRate, defaultPeakDecayRate)
+	userConfig.SetDefault(configKeySmoothingFactor, defaultSmoothingFactor)
+	userConfig.SetDefault(configKeySmoothingBypass, defaultSmoothingBypassThreshold)
+	userConfig.SetDefault(configKeyAttack, 0)
+	userConfig.SetDefault(configKeyRelease, 0)
+	userConfig.SetDefault(configKeyLogSerialLines, false)
+	userConfig.SetDefault(configKeyLineLogSampleRate, 0)
+	userConfig.SetDefault(configKeyThroughputInterval, 0)
+	userConfig.SetDefault(configKeyRawMode, rawModeADC)
+	userConfig.SetDefault(configKeyChecksum, checksumNone)
+	userConfig.SetDefault(configKeyFieldSeparator, defaultFieldSeparator)
+	userConfig.SetDefault(configKeyLineTerminator, defaultLineTerminator)
+	userConfig.SetDefault(configKeyProtocol, protocolText)
+	userConfig.SetDefault(configKeyBinaryByteOrder, byteOrderBig)
+	userConfig.SetDefault(configKeyIdleTimeout, 0)
+	userConfig.SetDefault(configKeyHotplugPollInterval, 0)
+	userConfig.SetDefault(configKeyRailWarningDelay, 0)
+	userConfig.SetDefault(configKeyStartupDelay, 0)
+	userConfig.SetDefault(configKeyCoalesceSliderEvents, false)
+	userConfig.SetDefault(configKeySliderEventTick, 0)
+	userConfig.SetDefault(configKeySliderEventQueueSize, 0)
+	userConfig.SetDefault(configKeyApplyOnRelease, 0)
+	userConfig.SetDefault(configKeyNoDedup, false)
+	userConfig.SetDefault(configKeyReconnectStopDelay, defaultReconnectStopDelay)
+	userConfig.SetDefault(configKeyReconnectInitial, defaultReconnectInitialBackoff)
+	userConfig.SetDefault(configKeyReconnectMax, defaultReconnectMaxBackoff)
+	userConfig.SetDefault(configKeyReconnectMaxAttempts, defaultReconnectMaxAttempts)
+	userConfig.SetDefault(configKeyReconnectJitter, defaultReconnectJitter)
+	userConfig.SetDefault(configKeyMaxStartupRetries, defaultMaxStartupRetries)
+	userConfig.SetDefault(advancedSerialKey(advancedKeyDataBits), defaultDataBits)
+	userConfig.SetDefault(advancedSerialKey(advancedKeyStopBits), defaultStopBits)
+	userConfig.SetDefault(configKeyAPIServerEnabled, false)
+	userConfig.SetDefault(configKeyAPIServerAddress, defaultAPIServerAddress)
+	userConfig.SetDefault(configKeyAPIServerToken, "")
+	userConfig.SetDefault(configKeyLineTracePath, "")
+	userConfig.SetDefault(configKeyLineTraceMaxSize, defaultLineTraceMaxSize)
+	userConfig.SetDefault(configKeyMaxLineLength, defaultMaxLineLength)
+	userConfig.SetDefault(configKeyApplyFailureFeedback, "")
+	userConfig.SetDefault(configKeyTestPatternRate, defaultTestPatternRate)
+	userConfig.SetDefault(configKeySimulate, "")
+	userConfig.SetDefault(configKeyMIDIOutputDevice, "")
+	userConfig.SetDefault(configKeyMIDIOutputChannel, defaultMIDIOutputChannel)
+	userConfig.SetDefault(configKeyMIDIControllers, map[string]int{})
 
 	internalConfig := viper.New()
 	internalConfig.SetConfigName(internalConfigName)
@@ -236,14 +601,838 @@ func (cc *CanonicalConfig) populateFromVipers() error {
 		cc.ConnectionInfo.BaudRate = defaultBaudRate
 	}
 
+	cc.ConnectionInfo.ReadBufferSize = cc.userConfig.GetInt(configKeyReadBufferSize)
+	if cc.ConnectionInfo.ReadBufferSize <= 0 {
+		cc.logger.Warnw("Invalid read buffer size specified, using default value",
+			"key", configKeyReadBufferSize,
+			"invalidValue", cc.ConnectionInfo.ReadBufferSize,
+			"defaultValue", defaultReadBufferSize)
+
+		cc.ConnectionInfo.ReadBufferSize = defaultReadBufferSize
+	}
+
+	// the default (-1) means "use the platform default", anything else below 0 is a mistake
+	cc.ConnectionInfo.MinimumReadSize = cc.userConfig.GetInt(configKeyMinimumReadSize)
+	if cc.ConnectionInfo.MinimumReadSize < defaultMinimumReadSize {
+		cc.logger.Warnw("Invalid minimum read size specified, using platform default",
+			"key", configKeyMinimumReadSize,
+			"invalidValue", cc.ConnectionInfo.MinimumReadSize)
+
+		cc.ConnectionInfo.MinimumReadSize = defaultMinimumReadSize
+	}
+
+	cc.ConnectionInfo.Parity = strings.ToLower(cc.userConfig.GetString(configKeyParity))
+	if _, err := parityMode(cc.ConnectionInfo.Parity); err != nil {
+		cc.logger.Warnw("Invalid parity specified, using default value",
+			"key", configKeyParity,
+			"invalidValue", cc.ConnectionInfo.Parity,
+			"defaultValue", defaultParity,
+			"error", err)
+
+		cc.ConnectionInfo.Parity = defaultParity
+	}
+
 	cc.InvertSliders = cc.userConfig.GetBool(configKeyInvertSliders)
+	cc.CurveBeforeInvert = cc.userConfig.GetBool(configKeyCurveBeforeInvert)
+	cc.LockedSliders = cc.userConfig.GetIntSlice(configKeyLockedSliders)
+	cc.EnabledSliders = cc.userConfig.GetIntSlice(configKeyEnabledSliders)
+	cc.DisabledSliders = cc.userConfig.GetIntSlice(configKeyDisabledSliders)
+	cc.BipolarSliders = cc.userConfig.GetIntSlice(configKeyBipolarSliders)
+
+	cc.SliderGamma = make(map[int]float32)
+	for sliderIdx, gamma := range cc.sliderNumbersFromConfig(configKeySliderGamma) {
+		if gamma <= 0 {
+			cc.logger.Warnw("Invalid slider gamma specified, ignoring",
+				"key", configKeySliderGamma,
+				"sliderIdx", sliderIdx,
+				"invalidValue", gamma)
+
+			continue
+		}
+
+		cc.SliderGamma[sliderIdx] = float32(gamma)
+	}
+
+	cc.SliderGain = make(map[int]float32)
+	for sliderIdx, gain := range cc.sliderNumbersFromConfig(configKeySliderGain) {
+		if gain <= 0 {
+			cc.logger.Warnw("Invalid slider gain specified, ignoring",
+				"key", configKeySliderGain,
+				"sliderIdx", sliderIdx,
+				"invalidValue", gain)
+
+			continue
+		}
+
+		cc.SliderGain[sliderIdx] = float32(gain)
+	}
+
+	// offsets are in raw units, and any of them is valid - the result gets clamped anyway
+	cc.SliderOffset = make(map[int]float32)
+	for sliderIdx, offset := range cc.sliderNumbersFromConfig(configKeySliderOffset) {
+		cc.SliderOffset[sliderIdx] = float32(offset)
+	}
+
+	cc.SliderCurves = make(map[int]sliderCurve)
+	for sliderIdxString, breakpointStrings := range cc.userConfig.GetStringMapStringSlice(configKeySliderCurves) {
+		sliderIdx, err := strconv.Atoi(sliderIdxString)
+		if err != nil || sliderIdx < 0 {
+			cc.logger.Warnw("Invalid slider index specified, ignoring",
+				"key", configKeySliderCurves,
+				"invalidValue", sliderIdxString)
+			continue
+		}
+
+		curve, err := parseSliderCurve(breakpointStrings)
+		if err != nil {
+			cc.logger.Warnw("Invalid slider curve specified, ignoring",
+				"key", configKeySliderCurves,
+				"sliderIdx", sliderIdx,
+				"invalidValue", breakpointStrings,
+				"error", err)
+			continue
+		}
+
+		cc.SliderCurves[sliderIdx] = curve
+	}
+
+	cc.SliderAttack = cc.sliderDurationsFromConfig(configKeySliderAttack)
+	cc.SliderRelease = cc.sliderDurationsFromConfig(configKeySliderRelease)
+
+	cc.SliderDeadzone = make(map[int]float32)
+	for sliderIdx, deadzone := range cc.sliderNumbersFromConfig(configKeySliderDeadzone) {
+		if deadzone < 0 || deadzone > 0.5 {
+			cc.logger.Warnw("Invalid slider deadzone specified, ignoring",
+				"key", configKeySliderDeadzone,
+				"sliderIdx", sliderIdx,
+				"invalidValue", deadzone)
+
+			continue
+		}
+
+		cc.SliderDeadzone[sliderIdx] = float32(deadzone)
+	}
+
+	cc.SliderSmoothing = make(map[int]float32)
+	for sliderIdx, factor := range cc.sliderNumbersFromConfig(configKeySliderSmoothing) {
+		if factor < 0 || factor >= 1 {
+			cc.logger.Warnw("Invalid slider smoothing factor specified, ignoring",
+				"key", configKeySliderSmoothing,
+				"sliderIdx", sliderIdx,
+				"invalidValue", factor)
+
+			continue
+		}
+
+		cc.SliderSmoothing[sliderIdx] = float32(factor)
+	}
+
+	cc.SliderSteps = make(map[int]int)
+	for sliderIdx, steps := range cc.sliderNumbersFromConfig(configKeySliderSteps) {
+		if steps < 2 || steps != math.Trunc(steps) {
+			cc.logger.Warnw("Invalid slider step count specified, ignoring",
+				"key", configKeySliderSteps,
+				"sliderIdx", sliderIdx,
+				"invalidValue", steps)
+
+			continue
+		}
+
+		cc.SliderSteps[sliderIdx] = int(steps)
+	}
+
 	cc.NoiseReductionLevel = cc.userConfig.GetString(configKeyNoiseReductionLevel)
 
+	cc.SliderTriggers = cc.sliderTriggersFromConfig()
+
+	cc.TriggerHysteresis = float32(cc.userConfig.GetFloat64(configKeyTriggerHysteresis))
+	if cc.TriggerHysteresis < 0 || cc.TriggerHysteresis > 0.5 {
+		cc.logger.Warnw("Invalid trigger hysteresis specified, using default value",
+			"key", configKeyTriggerHysteresis,
+			"invalidValue", cc.TriggerHysteresis,
+			"defaultValue", defaultTriggerHysteresis)
+
+		cc.TriggerHysteresis = defaultTriggerHysteresis
+	}
+
+	cc.RailDeadzone = float32(cc.userConfig.GetFloat64(configKeyRailDeadzone))
+	if cc.RailDeadzone < 0 || cc.RailDeadzone >= 0.5 {
+		cc.logger.Warnw("Invalid rail deadzone specified, disabling rail deadzone",
+			"key", configKeyRailDeadzone,
+			"invalidValue", cc.RailDeadzone)
+
+		cc.RailDeadzone = 0
+	}
+
+	cc.CenterDetent.Center = float32(cc.userConfig.GetFloat64(configKeyCenterDetent))
+	if cc.CenterDetent.Center < 0 || cc.CenterDetent.Center > 1 {
+		cc.logger.Warnw("Invalid center detent specified, using default value",
+			"key", configKeyCenterDetent,
+			"invalidValue", cc.CenterDetent.Center,
+			"defaultValue", defaultCenterDetent)
+
+		cc.CenterDetent.Center = defaultCenterDetent
+	}
+
+	cc.CenterDetent.Width = float32(cc.userConfig.GetFloat64(configKeyCenterDetentWidth))
+	if cc.CenterDetent.Width < 0 || cc.CenterDetent.Width > 0.5 {
+		cc.logger.Warnw("Invalid center detent width specified, disabling center detent",
+			"key", configKeyCenterDetentWidth,
+			"invalidValue", cc.CenterDetent.Width)
+
+		cc.CenterDetent.Width = 0
+	}
+
+	cc.Peaks.Hold = cc.durationFromConfig(configKeyPeakHold, defaultPeakHold)
+	if cc.Peaks.Hold < 0 {
+		cc.logger.Warnw("Invalid peak hold specified, using default value",
+			"key", configKeyPeakHold,
+			"invalidValue", cc.Peaks.Hold,
+			"defaultValue", defaultPeakHold)
+
+		cc.Peaks.Hold = defaultPeakHold
+	}
+
+	cc.Peaks.DecayRate = float32(cc.userConfig.GetFloat64(configKeyPeakDecayRate))
+	if cc.Peaks.DecayRate <= 0 {
+		cc.logger.Warnw("Invalid peak decay rate specified, using default value",
+			"key", configKeyPeakDecayRate,
+			"invalidValue", cc.Peaks.DecayRate,
+			"defaultValue", defaultPeakDecayRate)
+
+		cc.Peaks.DecayRate = defaultPeakDecayRate
+	}
+
+	cc.Smoothing.Factor = float32(cc.userConfig.GetFloat64(configKeySmoothingFactor))
+	if cc.Smoothing.Factor < 0 || cc.Smoothing.Factor >= 1 {
+		cc.logger.Warnw("Invalid smoothing factor specified, using default value",
+			"key", configKeySmoothingFactor,
+			"invalidValue", cc.Smoothing.Factor,
+			"defaultValue", defaultSmoothingFactor)
+
+		cc.Smoothing.Factor = defaultSmoothingFactor
+	}
+
+	cc.Smoothing.BypassThreshold = float32(cc.userConfig.GetFloat64(configKeySmoothingBypass))
+	if cc.Smoothing.BypassThreshold < 0 || cc.Smoothing.BypassThreshold > 1 {
+		cc.logger.Warnw("Invalid smoothing bypass threshold specified, using default value",
+			"key", configKeySmoothingBypass,
+			"invalidValue", cc.Smoothing.BypassThreshold,
+			"defaultValue", defaultSmoothingBypassThreshold)
+
+		cc.Smoothing.BypassThreshold = defaultSmoothingBypassThreshold
+	}
+
+	cc.Smoothing.Attack = cc.optionalDurationFromConfig(configKeyAttack)
+	cc.Smoothing.Release = cc.optionalDurationFromConfig(configKeyRelease)
+
+	cc.LogSerialLines = cc.userConfig.GetBool(configKeyLogSerialLines)
+
+	cc.LineLogSampleRate = cc.userConfig.GetInt(configKeyLineLogSampleRate)
+	if cc.LineLogSampleRate < 0 {
+		cc.logger.Warnw("Invalid line log sample rate specified, disabling line sampling",
+			"key", configKeyLineLogSampleRate,
+			"invalidValue", cc.LineLogSampleRate)
+
+		cc.LineLogSampleRate = 0
+	}
+
+	cc.ThroughputLogInterval = cc.durationFromConfig(configKeyThroughputInterval, 0)
+	if cc.ThroughputLogInterval < 0 {
+		cc.logger.Warnw("Invalid throughput log interval specified, disabling throughput logging",
+			"key", configKeyThroughputInterval,
+			"invalidValue", cc.ThroughputLogInterval)
+
+		cc.ThroughputLogInterval = 0
+	}
+
+	cc.RawMode = cc.userConfig.GetString(configKeyRawMode)
+	if cc.RawMode != rawModeADC && cc.RawMode != rawModePercent {
+		cc.logger.Warnw("Invalid raw mode specified, using default value",
+			"key", configKeyRawMode,
+			"invalidValue", cc.RawMode,
+			"defaultValue", rawModeADC)
+
+		cc.RawMode = rawModeADC
+	}
+
+	cc.Checksum = cc.userConfig.GetString(configKeyChecksum)
+	if cc.Checksum != checksumNone && cc.Checksum != checksumXOR && cc.Checksum != checksumCRC8 {
+		cc.logger.Warnw("Invalid checksum algorithm specified, using default value",
+			"key", configKeyChecksum,
+			"invalidValue", cc.Checksum,
+			"defaultValue", checksumNone)
+
+		cc.Checksum = checksumNone
+	}
+
+	cc.FieldSeparator = cc.userConfig.GetString(configKeyFieldSeparator)
+	if !validLineDelimiter(cc.FieldSeparator) {
+		cc.logger.Warnw("Invalid field separator specified, using default value",
+			"key", configKeyFieldSeparator,
+			"invalidValue", cc.FieldSeparator,
+			"defaultValue", defaultFieldSeparator)
+
+		cc.FieldSeparator = defaultFieldSeparator
+	}
+
+	cc.LineTerminator = cc.userConfig.GetString(configKeyLineTerminator)
+	if !validLineDelimiter(cc.LineTerminator) {
+		cc.logger.Warnw("Invalid line terminator specified, using default value",
+			"key", configKeyLineTerminator,
+			"invalidValue", cc.LineTerminator,
+			"defaultValue", defaultLineTerminator)
+
+		cc.LineTerminator = defaultLineTerminator
+	}
+
+	// they can't be the same, or we'd never see more than one value per line
+	if cc.LineTerminator == cc.FieldSeparator {
+		cc.logger.Warnw("Field separator can't be the same as the line terminator, using default value",
+			"key", configKeyFieldSeparator,
+			"invalidValue", cc.FieldSeparator,
+			"defaultValue", defaultFieldSeparator)
+
+		cc.FieldSeparator = defaultFieldSeparator
+	}
+
+	cc.Protocol = cc.userConfig.GetString(configKeyProtocol)
+	if cc.Protocol != protocolText && cc.Protocol != protocolBinary {
+		cc.logger.Warnw("Invalid protocol specified, using default value",
+			"key", configKeyProtocol,
+			"invalidValue", cc.Protocol,
+			"defaultValue", protocolText)
+
+		cc.Protocol = protocolText
+	}
+
+	cc.BinaryByteOrder = cc.userConfig.GetString(configKeyBinaryByteOrder)
+	if cc.BinaryByteOrder != byteOrderBig && cc.BinaryByteOrder != byteOrderLittle {
+		cc.logger.Warnw("Invalid binary byte order specified, using default value",
+			"key", configKeyBinaryByteOrder,
+			"invalidValue", cc.BinaryByteOrder,
+			"defaultValue", byteOrderBig)
+
+		cc.BinaryByteOrder = byteOrderBig
+	}
+
+	cc.IdleTimeout = cc.durationFromConfig(configKeyIdleTimeout, 0)
+	if cc.IdleTimeout < 0 {
+		cc.logger.Warnw("Invalid idle timeout specified, disabling idle disconnect",
+			"key", configKeyIdleTimeout,
+			"invalidValue", cc.IdleTimeout)
+
+		cc.IdleTimeout = 0
+	}
+
+	cc.HotplugPollInterval = cc.durationFromConfig(configKeyHotplugPollInterval, 0)
+	if cc.HotplugPollInterval < 0 {
+		cc.logger.Warnw("Invalid hotplug poll interval specified, disabling hotplug detection",
+			"key", configKeyHotplugPollInterval,
+			"invalidValue", cc.HotplugPollInterval)
+
+		cc.HotplugPollInterval = 0
+	}
+
+	cc.RailWarningDelay = cc.durationFromConfig(configKeyRailWarningDelay, 0)
+	if cc.RailWarningDelay < 0 {
+		cc.logger.Warnw("Invalid rail warning delay specified, disabling stuck slider detection",
+			"key", configKeyRailWarningDelay,
+			"invalidValue", cc.RailWarningDelay)
+
+		cc.RailWarningDelay = 0
+	}
+
+	cc.StartupDelay = cc.durationFromConfig(configKeyStartupDelay, 0)
+	if cc.StartupDelay < 0 {
+		cc.logger.Warnw("Invalid startup delay specified, disabling startup delay",
+			"key", configKeyStartupDelay,
+			"invalidValue", cc.StartupDelay)
+
+		cc.StartupDelay = 0
+	}
+
+	cc.ReconnectPolicy = cc.reconnectPolicyFromViper()
+
+	cc.MaxStartupRetries = cc.userConfig.GetInt(configKeyMaxStartupRetries)
+	if cc.MaxStartupRetries < -1 {
+		cc.logger.Warnw("Invalid max startup retries specified, using default value",
+			"key", configKeyMaxStartupRetries,
+			"invalidValue", cc.MaxStartupRetries,
+			"defaultValue", defaultMaxStartupRetries)
+
+		cc.MaxStartupRetries = defaultMaxStartupRetries
+	}
+
+	cc.AdvancedSerialOptions = cc.advancedSerialOptionsFromViper()
+
+	cc.CoalesceSliderEvents = cc.userConfig.GetBool(configKeyCoalesceSliderEvents)
+
+	cc.SliderEventTick = cc.durationFromConfig(configKeySliderEventTick, 0)
+	if cc.SliderEventTick < 0 {
+		cc.logger.Warnw("Invalid slider event tick specified, disabling tick coalescing",
+			"key", configKeySliderEventTick,
+			"invalidValue", cc.SliderEventTick)
+
+		cc.SliderEventTick = 0
+	}
+
+	cc.SliderEventQueueSize = cc.userConfig.GetInt(configKeySliderEventQueueSize)
+	if cc.SliderEventQueueSize < 0 {
+		cc.logger.Warnw("Invalid slider event queue size specified, delivering events directly",
+			"key", configKeySliderEventQueueSize,
+			"invalidValue", cc.SliderEventQueueSize)
+
+		cc.SliderEventQueueSize = 0
+	}
+
+	if cc.SliderEventQueueSize > 0 && cc.CoalesceSliderEvents {
+		cc.logger.Warnw("Slider event coalescing is enabled and takes precedence over per-consumer queues",
+			"key", configKeySliderEventQueueSize)
+	}
+
+	cc.ApplyOnRelease = cc.durationFromConfig(configKeyApplyOnRelease, 0)
+	if cc.ApplyOnRelease < 0 {
+		cc.logger.Warnw("Invalid apply on release window specified, applying moves right away",
+			"key", configKeyApplyOnRelease,
+			"invalidValue", cc.ApplyOnRelease)
+
+		cc.ApplyOnRelease = 0
+	}
+
+	cc.NoDedup = cc.userConfig.GetBool(configKeyNoDedup)
+	if cc.NoDedup && (cc.CoalesceSliderEvents || cc.SliderEventTick > 0) {
+		cc.logger.Warnw("Slider event coalescing is enabled, so some repeated events will still be dropped",
+			"key", configKeyNoDedup,
+			"coalesceSliderEvents", cc.CoalesceSliderEvents,
+			"sliderEventTick", cc.SliderEventTick)
+	}
+
+	cc.APIServer.Enabled = cc.userConfig.GetBool(configKeyAPIServerEnabled)
+	cc.APIServer.Address = cc.userConfig.GetString(configKeyAPIServerAddress)
+	cc.APIServer.Token = cc.userConfig.GetString(configKeyAPIServerToken)
+
+	cc.LineTrace.Path = cc.userConfig.GetString(configKeyLineTracePath)
+
+	cc.LineTrace.MaxSize = int64(cc.userConfig.GetInt(configKeyLineTraceMaxSize))
+	if cc.LineTrace.MaxSize <= 0 {
+		cc.logger.Warnw("Invalid line trace max size specified, using default value",
+			"key", configKeyLineTraceMaxSize,
+			"invalidValue", cc.LineTrace.MaxSize,
+			"defaultValue", defaultLineTraceMaxSize)
+
+		cc.LineTrace.MaxSize = defaultLineTraceMaxSize
+	}
+
+	cc.MIDIOutput.Device = cc.userConfig.GetString(configKeyMIDIOutputDevice)
+
+	cc.MIDIOutput.Channel = cc.userConfig.GetInt(configKeyMIDIOutputChannel)
+	if cc.MIDIOutput.Channel < 1 || cc.MIDIOutput.Channel > 16 {
+		cc.logger.Warnw("Invalid MIDI output channel specified, using default value",
+			"key", configKeyMIDIOutputChannel,
+			"invalidValue", cc.MIDIOutput.Channel,
+			"defaultValue", defaultMIDIOutputChannel)
+
+		cc.MIDIOutput.Channel = defaultMIDIOutputChannel
+	}
+
+	cc.MIDIOutput.Controllers = make(map[int]int)
+	for sliderIdx, controller := range cc.sliderNumbersFromConfig(configKeyMIDIControllers) {
+		if controller < 0 || controller > 127 || controller != float64(int(controller)) {
+			cc.logger.Warnw("Invalid MIDI controller number specified, ignoring",
+				"key", configKeyMIDIControllers,
+				"sliderIdx", sliderIdx,
+				"invalidValue", controller)
+
+			continue
+		}
+
+		cc.MIDIOutput.Controllers[sliderIdx] = int(controller)
+	}
+
+	cc.MaxLineLength = cc.userConfig.GetInt(configKeyMaxLineLength)
+	if cc.MaxLineLength <= 0 {
+		cc.logger.Warnw("Invalid max line length specified, using default value",
+			"key", configKeyMaxLineLength,
+			"invalidValue", cc.MaxLineLength,
+			"defaultValue", defaultMaxLineLength)
+
+		cc.MaxLineLength = defaultMaxLineLength
+	}
+
+	// feedback lines can't contain line breaks of their own, or the board would see them as several lines
+	cc.ApplyFailureFeedback = cc.userConfig.GetString(configKeyApplyFailureFeedback)
+	if strings.ContainsAny(cc.ApplyFailureFeedback, "\r\n") {
+		cc.logger.Warnw("Invalid apply failure feedback line specified, disabling apply failure feedback",
+			"key", configKeyApplyFailureFeedback,
+			"invalidValue", cc.ApplyFailureFeedback)
+
+		cc.ApplyFailureFeedback = ""
+	}
+
+	cc.TestPatternRate = cc.userConfig.GetInt(configKeyTestPatternRate)
+	if cc.TestPatternRate <= 0 {
+		cc.logger.Warnw("Invalid test pattern rate specified, using default value",
+			"key", configKeyTestPatternRate,
+			"invalidValue", cc.TestPatternRate,
+			"defaultValue", defaultTestPatternRate)
+
+		cc.TestPatternRate = defaultTestPatternRate
+	}
+
+	cc.Simulate = strings.ToLower(cc.userConfig.GetString(configKeySimulate))
+	if cc.Simulate != "" && cc.Simulate != testPatternSine &&
+		cc.Simulate != testPatternSweep && cc.Simulate != testPatternRandom {
+
+		cc.logger.Warnw("Invalid simulation pattern specified, disabling simulation",
+			"key", configKeySimulate,
+			"invalidValue", cc.Simulate)
+
+		cc.Simulate = ""
+	}
+
 	cc.logger.Debug("Populated config fields from vipers")
 
 	return nil
 }
 
+// validLineDelimiter checks that a field separator or line terminator is a single character that can't be
+// mistaken for part of a value, a checksum, or the carriage return preceding a line feed
+func validLineDelimiter(delimiter string) bool {
+	if len(delimiter) != 1 {
+		return false
+	}
+
+	c := delimiter[0]
+
+	return (c < '0' || c > '9') && c != '\r' && delimiter != checksumSeparator
+}
+
+// sliderNumbersFromConfig reads a mapping of slider indexes to numbers from the user config, skipping invalid entries
+func (cc *CanonicalConfig) sliderNumbersFromConfig(key string) map[int]float64 {
+	result := make(map[int]float64)
+
+	for sliderIdxString, value := range cc.userConfig.GetStringMap(key) {
+		sliderIdx, err := strconv.Atoi(sliderIdxString)
+		if err != nil || sliderIdx < 0 {
+			cc.logger.Warnw("Invalid slider index specified, ignoring", "key", key, "invalidValue", sliderIdxString)
+			continue
+		}
+
+		switch number := value.(type) {
+		case int:
+			result[sliderIdx] = float64(number)
+		case float64:
+			result[sliderIdx] = number
+		default:
+			cc.logger.Warnw("Non-numeric slider value specified, ignoring",
+				"key", key,
+				"sliderIdx", sliderIdx,
+				"invalidValue", value)
+		}
+	}
+
+	return result
+}
+
+// sliderTriggersFromConfig reads each slider's list of triggers, formatted as "<direction> <threshold>"
+// (i.e. "rising 0.8"), skipping invalid entries
+func (cc *CanonicalConfig) sliderTriggersFromConfig() map[int][]SliderTrigger {
+	result := make(map[int][]SliderTrigger)
+
+	for sliderIdxString, triggerStrings := range cc.userConfig.GetStringMapStringSlice(configKeySliderTriggers) {
+		sliderIdx, err := strconv.Atoi(sliderIdxString)
+		if err != nil || sliderIdx < 0 {
+			cc.logger.Warnw("Invalid slider index specified, ignoring",
+				"key", configKeySliderTriggers,
+				"invalidValue", sliderIdxString)
+			continue
+		}
+
+		for _, triggerString := range triggerStrings {
+			fields := strings.Fields(strings.ToLower(triggerString))
+			if len(fields) != 2 || (fields[0] != triggerDirectionRising && fields[0] != triggerDirectionFalling) {
+				cc.logger.Warnw("Invalid slider trigger specified, ignoring",
+					"key", configKeySliderTriggers,
+					"sliderIdx", sliderIdx,
+					"invalidValue", triggerString)
+				continue
+			}
+
+			threshold, err := strconv.ParseFloat(fields[1], 32)
+			if err != nil || threshold < 0 || threshold > 1 {
+				cc.logger.Warnw("Invalid slider trigger threshold specified, ignoring",
+					"key", configKeySliderTriggers,
+					"sliderIdx", sliderIdx,
+					"invalidValue", triggerString)
+				continue
+			}
+
+			result[sliderIdx] = append(result[sliderIdx], SliderTrigger{
+				Threshold: float32(threshold),
+				Rising:    fields[0] == triggerDirectionRising,
+			})
+		}
+	}
+
+	return result
+}
+
+// durationFromConfig reads a duration from the user config, falling back to the given default if it's invalid
+func (cc *CanonicalConfig) durationFromConfig(key string, defaultValue time.Duration) time.Duration {
+	value := cc.userConfig.Get(key)
+
+	duration, err := parseDuration(value)
+	if err != nil {
+		cc.logger.Warnw("Invalid duration specified, using default value",
+			"key", key,
+			"invalidValue", value,
+			"defaultValue", defaultValue,
+			"error", err)
+
+		return defaultValue
+	}
+
+	return duration
+}
+
+// optionalDurationFromConfig reads a non-negative duration from the user config, or 0 if it's invalid
+func (cc *CanonicalConfig) optionalDurationFromConfig(key string) time.Duration {
+	duration := cc.durationFromConfig(key, 0)
+	if duration < 0 {
+		cc.logger.Warnw("Negative duration specified, ignoring",
+			"key", key,
+			"invalidValue", duration)
+
+		return 0
+	}
+
+	return duration
+}
+
+// sliderDurationsFromConfig reads a mapping of slider indexes to durations from the user config
+func (cc *CanonicalConfig) sliderDurationsFromConfig(key string) map[int]time.Duration {
+	result := make(map[int]time.Duration)
+
+	for sliderIdxString, value := range cc.userConfig.GetStringMap(key) {
+		sliderIdx, err := strconv.Atoi(sliderIdxString)
+		if err != nil || sliderIdx < 0 {
+			cc.logger.Warnw("Invalid slider index specified, ignoring", "key", key, "invalidValue", sliderIdxString)
+			continue
+		}
+
+		duration, err := parseDuration(value)
+		if err != nil || duration < 0 {
+			cc.logger.Warnw("Invalid slider duration specified, ignoring",
+				"key", key,
+				"sliderIdx", sliderIdx,
+				"invalidValue", value,
+				"error", err)
+
+			continue
+		}
+
+		result[sliderIdx] = duration
+	}
+
+	return result
+}
+
+// parseDuration reads a config value as a duration. durations are always written with a unit (i.e. "500ms" or
+// "2s"), since a bare number is ambiguous - it'd be read as nanoseconds, which is almost never what was meant
+func parseDuration(value interface{}) (time.Duration, error) {
+	switch duration := value.(type) {
+	case time.Duration:
+		return duration, nil
+	case string:
+		return time.ParseDuration(strings.TrimSpace(duration))
+	case int:
+		return unitlessDuration(float64(duration))
+	case int64:
+		return unitlessDuration(float64(duration))
+	case float64:
+		return unitlessDuration(duration)
+	default:
+		return 0, fmt.Errorf("%v isn't a duration", value)
+	}
+}
+
+func unitlessDuration(number float64) (time.Duration, error) {
+
+	// zero is the same in every unit
+	if number == 0 {
+		return 0, nil
+	}
+
+	return 0, fmt.Errorf("duration %v is missing a unit, i.e. \"%vms\" or \"%vs\"", number, number, number)
+}
+
+func (cc *CanonicalConfig) reconnectPolicyFromViper() ReconnectPolicy {
+	rp := ReconnectPolicy{
+		StopDelay:      cc.durationFromConfig(configKeyReconnectStopDelay, defaultReconnectStopDelay),
+		InitialBackoff: cc.durationFromConfig(configKeyReconnectInitial, defaultReconnectInitialBackoff),
+		MaxBackoff:     cc.durationFromConfig(configKeyReconnectMax, defaultReconnectMaxBackoff),
+		MaxAttempts:    cc.userConfig.GetInt(configKeyReconnectMaxAttempts),
+		Jitter:         cc.userConfig.GetFloat64(configKeyReconnectJitter),
+	}
+
+	if rp.StopDelay < 0 {
+		cc.logger.Warnw("Invalid reconnect stop delay specified, using default value",
+			"key", configKeyReconnectStopDelay,
+			"invalidValue", rp.StopDelay,
+			"defaultValue", defaultReconnectStopDelay)
+
+		rp.StopDelay = defaultReconnectStopDelay
+	}
+
+	if rp.InitialBackoff <= 0 {
+		cc.logger.Warnw("Invalid reconnect initial backoff specified, using default value",
+			"key", configKeyReconnectInitial,
+			"invalidValue", rp.InitialBackoff,
+			"defaultValue", defaultReconnectInitialBackoff)
+
+		rp.InitialBackoff = defaultReconnectInitialBackoff
+	}
+
+	if rp.MaxBackoff < rp.InitialBackoff {
+		cc.logger.Warnw("Reconnect max backoff can't be shorter than the initial backoff, using initial backoff",
+			"key", configKeyReconnectMax,
+			"invalidValue", rp.MaxBackoff,
+			"initialBackoff", rp.InitialBackoff)
+
+		rp.MaxBackoff = rp.InitialBackoff
+	}
+
+	if rp.MaxAttempts < 0 {
+		cc.logger.Warnw("Invalid reconnect max attempts specified, using default value",
+			"key", configKeyReconnectMaxAttempts,
+			"invalidValue", rp.MaxAttempts,
+			"defaultValue", defaultReconnectMaxAttempts)
+
+		rp.MaxAttempts = defaultReconnectMaxAttempts
+	}
+
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		cc.logger.Warnw("Invalid reconnect jitter specified, using default value",
+			"key", configKeyReconnectJitter,
+			"invalidValue", rp.Jitter,
+			"defaultValue", defaultReconnectJitter)
+
+		rp.Jitter = defaultReconnectJitter
+	}
+
+	return rp
+}
+
+// backoff returns how long to wait before the given reconnect attempt (starting at 0). this doubles
+// with every attempt up to the maximum backoff, and is then randomized by up to the configured jitter
+func (rp ReconnectPolicy) backoff(attempt int) time.Duration {
+	backoff := rp.InitialBackoff
+	for i := 0; i < attempt && backoff < rp.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > rp.MaxBackoff {
+		backoff = rp.MaxBackoff
+	}
+
+	// spread it evenly around the nominal value
+	jitter := (rand.Float64()*2 - 1) * rp.Jitter * float64(backoff)
+
+	return backoff + time.Duration(jitter)
+}
+
+// exhausted returns true if no more reconnect attempts should be made after the given number of them
+func (rp ReconnectPolicy) exhausted(attempts int) bool {
+	return rp.MaxAttempts > 0 && attempts >= rp.MaxAttempts
+}
+
+// keys within the advanced serial options block
+const (
+	advancedKeyDataBits             = "data_bits"
+	advancedKeyStopBits             = "stop_bits"
+	advancedKeyRTSCTSFlowControl    = "rtscts_flow_control"
+	advancedKeyInterCharTimeout     = "inter_character_timeout"
+	advancedKeyRS485Enabled         = "rs485_enabled"
+	advancedKeyRS485RTSDuringSend   = "rs485_rts_high_during_send"
+	advancedKeyRS485RTSAfterSend    = "rs485_rts_high_after_send"
+	advancedKeyRS485RxDuringTx      = "rs485_rx_during_tx"
+	advancedKeyRS485DelayBeforeSend = "rs485_delay_rts_before_send"
+	advancedKeyRS485DelayAfterSend  = "rs485_delay_rts_after_send"
+)
+
+// these have their own top-level keys, and setting them in the advanced block would be ambiguous
+var advancedSerialPrimaryKeys = map[string]string{
+	"port_name":         configKeyCOMPort,
+	"com_port":          configKeyCOMPort,
+	"baud_rate":         configKeyBaudRate,
+	"parity":            configKeyParity,
+	"parity_mode":       configKeyParity,
+	"minimum_read_size": configKeyMinimumReadSize,
+}
+
+func advancedSerialKey(key string) string {
+	return configKeyAdvancedSerial + "." + key
+}
+
+func (cc *CanonicalConfig) advancedSerialOptionsFromViper() AdvancedSerialOptions {
+	for key := range cc.userConfig.GetStringMap(configKeyAdvancedSerial) {
+		key = strings.ToLower(key)
+
+		if primaryKey, ok := advancedSerialPrimaryKeys[key]; ok {
+			cc.logger.Warnw("Primary connection option can't be set in advanced serial options, ignoring it",
+				"key", advancedSerialKey(key),
+				"useInstead", primaryKey)
+
+			continue
+		}
+
+		switch key {
+		case advancedKeyDataBits, advancedKeyStopBits, advancedKeyRTSCTSFlowControl, advancedKeyInterCharTimeout,
+			advancedKeyRS485Enabled, advancedKeyRS485RTSDuringSend, advancedKeyRS485RTSAfterSend,
+			advancedKeyRS485RxDuringTx, advancedKeyRS485DelayBeforeSend, advancedKeyRS485DelayAfterSend:
+		default:
+			cc.logger.Warnw("Unknown advanced serial option, ignoring it", "key", advancedSerialKey(key))
+		}
+	}
+
+	aso := AdvancedSerialOptions{
+		RTSCTSFlowControl:       cc.userConfig.GetBool(advancedSerialKey(advancedKeyRTSCTSFlowControl)),
+		InterCharacterTimeout:   cc.optionalDurationFromConfig(advancedSerialKey(advancedKeyInterCharTimeout)),
+		RS485Enabled:            cc.userConfig.GetBool(advancedSerialKey(advancedKeyRS485Enabled)),
+		RS485RTSHighDuringSend:  cc.userConfig.GetBool(advancedSerialKey(advancedKeyRS485RTSDuringSend)),
+		RS485RTSHighAfterSend:   cc.userConfig.GetBool(advancedSerialKey(advancedKeyRS485RTSAfterSend)),
+		RS485RxDuringTx:         cc.userConfig.GetBool(advancedSerialKey(advancedKeyRS485RxDuringTx)),
+		RS485DelayRTSBeforeSend: cc.optionalDurationFromConfig(advancedSerialKey(advancedKeyRS485DelayBeforeSend)),
+		RS485DelayRTSAfterSend:  cc.optionalDurationFromConfig(advancedSerialKey(advancedKeyRS485DelayAfterSend)),
+	}
+
+	dataBits := cc.userConfig.GetInt(advancedSerialKey(advancedKeyDataBits))
+	if dataBits < 5 || dataBits > 8 {
+		cc.logger.Warnw("Invalid data bits specified, using default value",
+			"key", advancedSerialKey(advancedKeyDataBits),
+			"invalidValue", dataBits,
+			"defaultValue", defaultDataBits)
+
+		dataBits = defaultDataBits
+	}
+
+	stopBits := cc.userConfig.GetInt(advancedSerialKey(advancedKeyStopBits))
+	if stopBits != 1 && stopBits != 2 {
+		cc.logger.Warnw("Invalid stop bits specified, using default value",
+			"key", advancedSerialKey(advancedKeyStopBits),
+			"invalidValue", stopBits,
+			"defaultValue", defaultStopBits)
+
+		stopBits = defaultStopBits
+	}
+
+	aso.DataBits = uint(dataBits)
+	aso.StopBits = uint(stopBits)
+
+	return aso
+}
+
 func (cc *CanonicalConfig) onConfigReloaded() {
 	cc.logger.Debug("Notifying consumers about configuration reload")
 
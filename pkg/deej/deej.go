@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -25,10 +26,17 @@ type Deej struct {
 	config   *CanonicalConfig
 	serial   *SerialIO
 	sessions *sessionMap
+	api      *apiServer
+	midi     *midiOutput
 
 	stopChannel chan bool
 	version     string
 	verbose     bool
+
+	// closed once deej starts stopping, to cut short anything still waiting to retry
+	shutdownChannel chan bool
+
+	connectionFailedCallbacks []func(err error)
 }
 
 // NewDeej creates a Deej instance
@@ -53,6 +61,8 @@ func NewDeej(logger *zap.SugaredLogger, verbose bool) (*Deej, error) {
 		config:      config,
 		stopChannel: make(chan bool),
 		verbose:     verbose,
+
+		shutdownChannel: make(chan bool),
 	}
 
 	serial, err := NewSerialIO(d, logger)
@@ -77,6 +87,15 @@ func NewDeej(logger *zap.SugaredLogger, verbose bool) (*Deej, error) {
 
 	d.sessions = sessions
 
+	api, err := newAPIServer(d, logger)
+	if err != nil {
+		logger.Errorw("Failed to create API server", "error", err)
+		return nil, fmt.Errorf("create new API server: %w", err)
+	}
+
+	d.api = api
+	d.midi = newMIDIOutput(d, logger)
+
 	logger.Debug("Created deej instance")
 
 	return d, nil
@@ -120,6 +139,12 @@ func (d *Deej) SetVersion(version string) {
 	d.version = version
 }
 
+// OnConnectionFailed registers a callback for when deej gives up on its first serial connection,
+// i.e. to send an alert on a headless machine. It must be called before Initialize
+func (d *Deej) OnConnectionFailed(callback func(err error)) {
+	d.connectionFailedCallbacks = append(d.connectionFailedCallbacks, callback)
+}
+
 // Verbose returns a boolean indicating whether deej is running in verbose mode
 func (d *Deej) Verbose() bool {
 	return d.verbose
@@ -141,33 +166,22 @@ func (d *Deej) run() {
 	// watch the config file for changes
 	go d.config.WatchConfigFileChanges()
 
-	// connect to the arduino for the first time
-	go func() {
-		if err := d.serial.Start(); err != nil {
-			d.logger.Warnw("Failed to start first-time serial connection", "error", err)
-
-			// If the port is busy, that's because something else is connected - notify and quit
-			if errors.Is(err, os.ErrPermission) {
-				d.logger.Warnw("Serial port seems busy, notifying user and closing",
-					"comPort", d.config.ConnectionInfo.COMPort)
-
-				d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", d.config.ConnectionInfo.COMPort),
-					"This serial port is busy, make sure to close any serial monitor or other deej instance.")
-
-				d.signalStop()
-
-				// also notify if the COM port they gave isn't found, maybe their config is wrong
-			} else if errors.Is(err, os.ErrNotExist) {
-				d.logger.Warnw("Provided COM port seems wrong, notifying user and closing",
-					"comPort", d.config.ConnectionInfo.COMPort)
-
-				d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", d.config.ConnectionInfo.COMPort),
-					"This serial port doesn't exist, check your configuration and make sure it's set correctly.")
+	// serve slider activity over HTTP, if enabled
+	if d.config.APIServer.Enabled {
+		if err := d.api.start(); err != nil {
+			d.logger.Warnw("Failed to start API server", "error", err)
+		}
+	}
 
-				d.signalStop()
-			}
+	// send slider moves out over MIDI, if enabled
+	if d.config.MIDIOutput.Device != "" {
+		if err := d.midi.start(d.config.MIDIOutput.Device); err != nil {
+			d.logger.Warnw("Failed to start MIDI output, continuing without it", "error", err)
 		}
-	}()
+	}
+
+	// start reading slider values, from the board or otherwise
+	d.startSliderInput()
 
 	// wait until stopped (gracefully)
 	<-d.stopChannel
@@ -182,6 +196,198 @@ func (d *Deej) run() {
 	}
 }
 
+// startSliderInput either connects to the board or, when simulating, has synthetic slider activity take
+// its place entirely. this is only decided on startup, so the two are never mixed
+func (d *Deej) startSliderInput() {
+	if d.config.Simulate != "" {
+		d.logger.Infow("Simulating slider activity instead of connecting to serial", "pattern", d.config.Simulate)
+
+		if err := d.serial.StartTestPattern(d.config.Simulate); err != nil {
+			d.logger.Warnw("Failed to start slider simulation", "error", err)
+		}
+
+		return
+	}
+
+	// connect to the arduino for the first time
+	go d.connectFirstTime()
+
+	// and whenever it's plugged back in, if asked to. like simulation, this is only decided on startup
+	if d.config.HotplugPollInterval > 0 {
+		if err := d.serial.StartHotplugWatcher(d.config.HotplugPollInterval); err != nil {
+			d.logger.Warnw("Failed to start hotplug watcher", "error", err)
+		}
+	}
+}
+
+func (d *Deej) connectFirstTime() {
+	comPort := d.config.ConnectionInfo.COMPort
+	policy := d.config.ReconnectPolicy
+
+	for attempt := 0; ; attempt++ {
+		if d.shuttingDown() {
+			return
+		}
+
+		err := d.serial.Start()
+		if err == nil {
+			return
+		}
+
+		d.logger.Warnw("Failed to start first-time serial connection", "error", err, "attempt", attempt+1)
+
+		// if the port is busy, that's because something else is connected. that something (i.e. a serial monitor)
+		// often lets go of it shortly, so retry on a backoff unless the user asked us not to
+		if errors.Is(err, os.ErrPermission) {
+			if d.startupRetriesExhausted(attempt + 1) {
+				d.logger.Warnw("Serial port still busy, giving up and closing",
+					"comPort", comPort,
+					"attempts", attempt+1)
+
+				d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", comPort),
+					"This serial port is busy, make sure to close any serial monitor or other deej instance.")
+
+				d.connectionFailed(err)
+				d.signalStop()
+				return
+			}
+
+			// only notify once, rather than on every attempt
+			if attempt == 0 {
+				d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", comPort),
+					"This serial port is busy, deej will keep trying to connect. Close any serial monitor or other deej instance.")
+			}
+
+			backoff := policy.backoff(attempt)
+			d.logger.Infow("Serial port seems busy, retrying after backoff",
+				"comPort", comPort,
+				"attempt", attempt+1,
+				"backoff", backoff)
+
+			if !d.waitForRetry(backoff) {
+				return
+			}
+
+			continue
+		}
+
+		// flaky adapters sometimes fail their first read, but usually come around after being reopened
+		if errors.Is(err, errImmediateReadFailure) {
+			if d.startupRetriesExhausted(attempt + 1) {
+				d.logger.Warnw("Serial connection keeps failing right after opening, giving up",
+					"comPort", comPort,
+					"attempts", attempt+1)
+
+				d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", comPort),
+					"The serial connection keeps failing right after opening. Try reconnecting your board.")
+
+				d.connectionFailed(err)
+				return
+			}
+
+			backoff := policy.backoff(attempt)
+			d.logger.Infow("Serial connection failed right after opening, retrying after backoff",
+				"comPort", comPort,
+				"attempt", attempt+1,
+				"backoff", backoff)
+
+			if !d.waitForRetry(backoff) {
+				return
+			}
+
+			continue
+		}
+
+		// with an explicit retry limit, anything else is retried too (the board may just not be plugged in yet)
+		if d.config.MaxStartupRetries > 0 && !d.startupRetriesExhausted(attempt+1) {
+			backoff := policy.backoff(attempt)
+			d.logger.Infow("Retrying first-time serial connection after backoff",
+				"comPort", comPort,
+				"attempt", attempt+1,
+				"backoff", backoff)
+
+			if !d.waitForRetry(backoff) {
+				return
+			}
+
+			continue
+		}
+
+		// if we're watching for the board to be plugged in, a missing port just means it isn't yet
+		if errors.Is(err, os.ErrNotExist) && d.config.HotplugPollInterval > 0 {
+			d.logger.Infow("Serial port not found, waiting for it to be plugged in", "comPort", comPort)
+
+			d.notifier.Notify(fmt.Sprintf("Waiting for %s", comPort),
+				"This serial port doesn't exist yet, deej will connect to it once your board is plugged in.")
+
+			return
+		}
+
+		// also notify if the COM port they gave isn't found, maybe their config is wrong
+		if errors.Is(err, os.ErrNotExist) {
+			d.logger.Warnw("Provided COM port seems wrong, notifying user and closing",
+				"comPort", comPort)
+
+			d.notifier.Notify(fmt.Sprintf("Can't connect to %s!", comPort),
+				"This serial port doesn't exist, check your configuration and make sure it's set correctly.")
+
+			d.connectionFailed(err)
+			d.signalStop()
+			return
+		}
+
+		d.connectionFailed(err)
+		return
+	}
+}
+
+// startupRetriesExhausted returns true if no more first-time connection attempts should be made after the given
+// number of them. an explicit retry limit takes precedence over the reconnect policy's own
+func (d *Deej) startupRetriesExhausted(attempts int) bool {
+	if d.config.MaxStartupRetries < 0 {
+		return d.config.ReconnectPolicy.exhausted(attempts)
+	}
+
+	return attempts > d.config.MaxStartupRetries
+}
+
+// waitForRetry waits out the given backoff, and returns false if deej started stopping in the meantime
+func (d *Deej) waitForRetry(backoff time.Duration) bool {
+	select {
+	case <-d.shutdownChannel:
+		return false
+	case <-time.After(backoff):
+		return true
+	}
+}
+
+func (d *Deej) shuttingDown() bool {
+	select {
+	case <-d.shutdownChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Deej) connectionFailed(err error) {
+	d.logger.Debugw("Invoking connection failure callbacks", "amount", len(d.connectionFailedCallbacks), "error", err)
+
+	for _, callback := range d.connectionFailedCallbacks {
+		d.invokeConnectionFailedCallback(callback, err)
+	}
+}
+
+func (d *Deej) invokeConnectionFailedCallback(callback func(err error), err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Errorw("Recovered from panic in connection failure callback", "error", r, "connectionError", err)
+		}
+	}()
+
+	callback(err)
+}
+
 func (d *Deej) signalStop() {
 	d.logger.Debug("Signalling stop channel")
 	d.stopChannel <- true
@@ -190,9 +396,19 @@ func (d *Deej) signalStop() {
 func (d *Deej) stop() error {
 	d.logger.Info("Stopping")
 
+	close(d.shutdownChannel)
+
 	d.config.StopWatchingConfigFile()
+
+	d.serial.StopHotplugWatcher()
 	d.serial.Stop()
 
+	if err := d.api.stop(); err != nil {
+		d.logger.Warnw("Failed to stop API server", "error", err)
+	}
+
+	d.midi.stop()
+
 	// release the session map
 	if err := d.sessions.release(); err != nil {
 		d.logger.Errorw("Failed to release session map", "error", err)
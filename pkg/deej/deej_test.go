@@ -0,0 +1,197 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"go.uber.org/zap"
+)
+
+// testNotifier keeps the titles of every notification instead of showing them
+type testNotifier struct {
+	titles []string
+	lock   sync.Mutex
+}
+
+func (n *testNotifier) Notify(title string, message string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.titles = append(n.titles, title)
+}
+
+func TestConnectionFailedRecoversFromPanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		panic func()
+	}{
+		{
+			name:  "string",
+			panic: func() { panic("oops") },
+		},
+		{
+			name:  "error",
+			panic: func() { panic(errors.New("oops")) },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &Deej{logger: zap.NewNop().Sugar()}
+			connectionErr := errors.New("no such port")
+
+			// callbacks registered on either side of the panicking one still get called
+			got := []error{}
+			d.OnConnectionFailed(func(err error) { got = append(got, err) })
+			d.OnConnectionFailed(func(error) { test.panic() })
+			d.OnConnectionFailed(func(err error) { got = append(got, err) })
+
+			d.connectionFailed(connectionErr)
+
+			if len(got) != 2 || got[0] != connectionErr || got[1] != connectionErr {
+				t.Errorf("healthy callbacks got %v, want the connection error twice", got)
+			}
+		})
+	}
+}
+
+func TestConnectFirstTimeRetriesBusyPort(t *testing.T) {
+	busyErr := fmt.Errorf("open COM4: %w", os.ErrPermission)
+
+	tests := []struct {
+		name              string
+		maxStartupRetries int
+		openErrors        []error
+		wantOpens         int
+		wantConnected     bool
+		wantFailed        bool
+	}{
+		{
+			name:              "busy, then free with the default config",
+			maxStartupRetries: defaultMaxStartupRetries,
+			openErrors:        []error{busyErr, busyErr},
+			wantOpens:         3,
+			wantConnected:     true,
+		},
+		{
+			name:              "busy with retries turned off",
+			maxStartupRetries: 0,
+			openErrors:        []error{busyErr},
+			wantOpens:         1,
+			wantFailed:        true,
+		},
+		{
+			name:              "busy for longer than the retry limit",
+			maxStartupRetries: 1,
+			openErrors:        []error{busyErr, busyErr, busyErr},
+			wantOpens:         2,
+			wantFailed:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{MaxStartupRetries: test.maxStartupRetries}
+			config.ReconnectPolicy.InitialBackoff = time.Millisecond
+			config.ReconnectPolicy.MaxBackoff = time.Millisecond
+
+			sio := newTestSerialIO(t, config)
+
+			d := sio.deej
+			d.notifier = &testNotifier{}
+			d.stopChannel = make(chan bool, 1)
+			d.shutdownChannel = make(chan bool)
+
+			// once the port's free, the board sends a line to discard and a valid one
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte("0\r\n512\r\n"))
+
+			opens := 0
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				opens++
+				if opens <= len(test.openErrors) {
+					return nil, test.openErrors[opens-1]
+				}
+
+				return &testConn{Reader: reader}, nil
+			}
+
+			failed := false
+			d.OnConnectionFailed(func(error) { failed = true })
+
+			d.connectFirstTime()
+			defer sio.Stop()
+
+			if opens != test.wantOpens {
+				t.Errorf("opened the port %d times, want %d", opens, test.wantOpens)
+			}
+
+			if connected := sio.Connected(); connected != test.wantConnected {
+				t.Errorf("connected = %v, want %v", connected, test.wantConnected)
+			}
+
+			if failed != test.wantFailed {
+				t.Errorf("connection failure reported = %v, want %v", failed, test.wantFailed)
+			}
+		})
+	}
+}
+
+func TestStartSliderInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		simulate   string
+		wantOpened bool
+	}{
+		{name: "board", wantOpened: true},
+		{name: "simulated sweep", simulate: testPatternSweep},
+		{name: "simulated sine", simulate: testPatternSine},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{Simulate: test.simulate, TestPatternRate: 1000}
+			config.SliderMapping = newSliderMap()
+			config.SliderMapping.set(0, []string{"master"})
+
+			sio := newTestSerialIO(t, config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			d := sio.deej
+			d.notifier = &testNotifier{}
+			d.stopChannel = make(chan bool, 1)
+			d.shutdownChannel = make(chan bool)
+
+			// there's no board, so connecting fails for good right away
+			opened := make(chan bool, 1)
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				opened <- true
+				return nil, errors.New("no such port")
+			}
+
+			d.startSliderInput()
+			defer collectTestEvents(events, sio.StopTestPattern)
+
+			select {
+			case <-opened:
+				if !test.wantOpened {
+					t.Fatal("opened the port while simulating")
+				}
+			case event := <-events:
+				if test.wantOpened {
+					t.Fatalf("got simulated event %+v while connecting to the board", event)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("got neither a port opening nor a simulated event")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package deej
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// lineFormat describes how the board separates slider values and terminates lines in text mode
+type lineFormat struct {
+	separator  string
+	terminator byte
+
+	// everything that ends a line. with a custom terminator, LF also ends one - those lines aren't values,
+	// but debug prints and the like that firmware sends along with them, and they shouldn't break up value lines
+	terminators string
+
+	// trimmed off the end of every line before it's split into values
+	suffix string
+
+	// the checksum algorithm lines are verified with, or checksumNone
+	checksum string
+
+	pattern *regexp.Regexp
+}
+
+// formattedLine is a line along with the format it was read with
+type formattedLine struct {
+	text   string
+	format *lineFormat
+}
+
+func newLineFormat(separator string, terminator string, checksum string) *lineFormat {
+
+	// the stock sketch uses println, which terminates lines with CRLF - keep requiring that
+	// when using the default terminator, so that we're not any more lenient than we used to be
+	suffix := terminator
+	if terminator == "\n" {
+		suffix = "\r\n"
+	}
+
+	checksumPattern := ""
+	if checksum != checksumNone {
+		checksumPattern = `\*[0-9A-Fa-f]{2}`
+	}
+
+	pattern := fmt.Sprintf(`^\d{1,4}(%s\d{1,4})*%s%s$`,
+		regexp.QuoteMeta(separator), checksumPattern, regexp.QuoteMeta(suffix))
+
+	terminators := terminator
+	if terminator != "\n" {
+		terminators += "\n"
+	}
+
+	return &lineFormat{
+		separator:   separator,
+		terminator:  terminator[0],
+		terminators: terminators,
+		suffix:      suffix,
+		checksum:    checksum,
+		pattern:     regexp.MustCompile(pattern),
+	}
+}
+
+// matches returns whether both formats parse lines the same way
+func (lf *lineFormat) matches(other *lineFormat) bool {
+	return lf.separator == other.separator && lf.terminator == other.terminator &&
+		lf.checksum == other.checksum && lf.pattern.String() == other.pattern.String()
+}
+
+// isValueLine returns whether the given line ended with the value line terminator, as opposed to any other one
+func (lf *lineFormat) isValueLine(line string) bool {
+	return len(line) > 0 && line[len(line)-1] == lf.terminator
+}
+
+// readSliceAny works like bufio.Reader's ReadSlice, but stops at the first of any of the given delimiters.
+// like ReadSlice, the returned slice is only valid until the next read. when there's no delimiter in what's
+// currently buffered, it's all returned along with bufio.ErrBufferFull to signal that the line goes on
+func readSliceAny(reader *bufio.Reader, delimiters string) ([]byte, error) {
+	if len(delimiters) == 1 {
+		return reader.ReadSlice(delimiters[0])
+	}
+
+	// wait for something to look at
+	if _, err := reader.Peek(1); err != nil {
+		return nil, err
+	}
+
+	buffered, _ := reader.Peek(reader.Buffered())
+
+	chunk := buffered
+	err := bufio.ErrBufferFull
+
+	if idx := bytes.IndexAny(buffered, delimiters); idx != -1 {
+		chunk = buffered[:idx+1]
+		err = nil
+	}
+
+	// discarding doesn't touch the buffer's contents, so the chunk stays valid until the next read
+	if _, discardErr := reader.Discard(len(chunk)); discardErr != nil {
+		return chunk, discardErr
+	}
+
+	return chunk, err
+}
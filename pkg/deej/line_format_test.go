@@ -0,0 +1,158 @@
+package deej
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineFormatPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		separator  string
+		terminator string
+		checksum   string
+		line       string
+		want       bool
+	}{
+		{"default", "|", "\n", checksumNone, "512|300|1023\r\n", true},
+		{"default single slider", "|", "\n", checksumNone, "512\r\n", true},
+		{"default without CR", "|", "\n", checksumNone, "512|300\n", false},
+		{"default with too many digits", "|", "\n", checksumNone, "51234|300\r\n", false},
+		{"default with empty value", "|", "\n", checksumNone, "512||300\r\n", false},
+		{"comma", ",", "\n", checksumNone, "512,300,1023\r\n", true},
+		{"comma rejects pipes", ",", "\n", checksumNone, "512|300\r\n", false},
+		{"comma rejects trailing separator", ",", "\n", checksumNone, "512,300,\r\n", false},
+		{"regex metacharacter separator", ".", "\n", checksumNone, "512.300\r\n", true},
+		{"regex metacharacter separator is literal", ".", "\n", checksumNone, "512x300\r\n", false},
+		{"custom terminator", ",", ";", checksumNone, "512,300;", true},
+		{"custom terminator doesn't need CR", ",", ";", checksumNone, "512,300\r\n", false},
+		{"checksum", "|", "\n", checksumXOR, "512|300*3F\r\n", true},
+		{"checksum lowercase hex", "|", "\n", checksumCRC8, "512|300*af\r\n", true},
+		{"checksum missing", "|", "\n", checksumXOR, "512|300\r\n", false},
+		{"checksum when not expected", "|", "\n", checksumNone, "512|300*3F\r\n", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			format := newLineFormat(test.separator, test.terminator, test.checksum)
+
+			if got := format.pattern.MatchString(test.line); got != test.want {
+				t.Errorf("pattern %q matching %q = %v, want %v", format.pattern, test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLineFormatMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     *lineFormat
+		b     *lineFormat
+		equal bool
+	}{
+		{"same", newLineFormat("|", "\n", checksumNone), newLineFormat("|", "\n", checksumNone), true},
+		{"different separator", newLineFormat("|", "\n", checksumNone), newLineFormat(",", "\n", checksumNone), false},
+		{"different terminator", newLineFormat("|", "\n", checksumNone), newLineFormat("|", ";", checksumNone), false},
+		{"different checksum", newLineFormat("|", "\n", checksumNone), newLineFormat("|", "\n", checksumXOR), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.a.matches(test.b); got != test.equal {
+				t.Errorf("matches = %v, want %v", got, test.equal)
+			}
+		})
+	}
+}
+
+func TestLineFormatIsValueLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		terminator string
+		line       string
+		want       bool
+	}{
+		{"default", "\n", "512|300\r\n", true},
+		{"empty", "\n", "", false},
+		{"custom terminator", ";", "512|300;", true},
+		{"LF with a custom terminator", ";", "debug print\n", false},
+		{"unterminated", ";", "512|300", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			format := newLineFormat("|", test.terminator, checksumNone)
+
+			if got := format.isValueLine(test.line); got != test.want {
+				t.Errorf("isValueLine(%q) = %v, want %v", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadSliceAny(t *testing.T) {
+	type chunk struct {
+		text   string
+		isFull bool
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		delimiters string
+		bufferSize int
+		want       []chunk
+	}{
+		{
+			name:       "single delimiter",
+			input:      "a\nbc\n",
+			delimiters: "\n",
+			want:       []chunk{{text: "a\n"}, {text: "bc\n"}},
+		},
+		{
+			name:       "first of several delimiters",
+			input:      "a;b\nc;",
+			delimiters: ";\n",
+			want:       []chunk{{text: "a;"}, {text: "b\n"}, {text: "c;"}},
+		},
+		{
+			name:       "no delimiter within the buffer",
+			input:      strings.Repeat("x", 20) + ";",
+			delimiters: ";\n",
+			bufferSize: 16,
+			want:       []chunk{{text: strings.Repeat("x", 16), isFull: true}, {text: "xxxx;"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bufferSize := test.bufferSize
+			if bufferSize == 0 {
+				bufferSize = defaultReadBufferSize
+			}
+
+			reader := bufio.NewReaderSize(strings.NewReader(test.input), bufferSize)
+
+			got := []chunk{}
+			for {
+				slice, err := readSliceAny(reader, test.delimiters)
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil && err != bufio.ErrBufferFull {
+					t.Fatalf("readSliceAny returned an unexpected error: %v", err)
+				}
+
+				got = append(got, chunk{text: string(slice), isFull: err == bufio.ErrBufferFull})
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got chunks %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package deej
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lineTracer writes a machine-readable record of every line deej processes, one JSON object per line.
+// unlike debug logging, this is meant for offline analysis of user-submitted traces
+type lineTracer struct {
+	logger *zap.SugaredLogger
+
+	path    string
+	maxSize int64
+
+	file *os.File
+	size int64
+	lock sync.Locker
+}
+
+// lineTraceRecord describes what happened to a single line read from serial
+type lineTraceRecord struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Line       string            `json:"line"`
+	Accepted   bool              `json:"accepted"`
+	DropReason string            `json:"dropReason,omitempty"`
+	Events     []SliderMoveEvent `json:"events,omitempty"`
+}
+
+const (
+	lineTraceDropReasonMalformed  = "malformed"
+	lineTraceDropReasonOutOfRange = "out of range"
+	lineTraceDropReasonChecksum   = "checksum mismatch"
+
+	// the previous trace file gets this suffix when the current one grows beyond the maximum size
+	lineTraceRotatedSuffix = ".1"
+)
+
+func newLineTracer(logger *zap.SugaredLogger, path string, maxSize int64) (*lineTracer, error) {
+	logger = logger.Named("trace")
+
+	lt := &lineTracer{
+		logger:  logger,
+		path:    path,
+		maxSize: maxSize,
+		lock:    &sync.Mutex{},
+	}
+
+	if err := lt.open(); err != nil {
+		return nil, fmt.Errorf("open line trace file: %w", err)
+	}
+
+	logger.Debugw("Created line tracer instance", "path", path, "maxSize", maxSize)
+
+	return lt, nil
+}
+
+func (lt *lineTracer) trace(record lineTraceRecord) {
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	if lt.file == nil {
+		return
+	}
+
+	record.Timestamp = time.Now()
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		lt.logger.Warnw("Failed to marshal line trace record", "error", err)
+		return
+	}
+
+	payload = append(payload, '\n')
+
+	if lt.size+int64(len(payload)) > lt.maxSize {
+		if err := lt.rotate(); err != nil {
+			lt.logger.Warnw("Failed to rotate line trace file, disabling line trace", "error", err)
+			return
+		}
+	}
+
+	written, err := lt.file.Write(payload)
+	lt.size += int64(written)
+
+	if err != nil {
+		lt.logger.Warnw("Failed to write line trace record", "error", err)
+	}
+}
+
+func (lt *lineTracer) close() error {
+	lt.lock.Lock()
+	defer lt.lock.Unlock()
+
+	if lt.file == nil {
+		return nil
+	}
+
+	err := lt.file.Close()
+	lt.file = nil
+
+	if err != nil {
+		return fmt.Errorf("close line trace file: %w", err)
+	}
+
+	return nil
+}
+
+// assumes the lock is held (or the tracer isn't shared yet)
+func (lt *lineTracer) open() error {
+	file, err := os.OpenFile(lt.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	lt.file = file
+	lt.size = info.Size()
+
+	return nil
+}
+
+// assumes the lock is held
+func (lt *lineTracer) rotate() error {
+	lt.logger.Debugw("Rotating line trace file", "path", lt.path, "size", lt.size)
+
+	if err := lt.file.Close(); err != nil {
+		lt.logger.Warnw("Failed to close line trace file before rotating", "error", err)
+	}
+
+	lt.file = nil
+
+	if err := os.Rename(lt.path, lt.path+lineTraceRotatedSuffix); err != nil {
+		return fmt.Errorf("rename line trace file: %w", err)
+	}
+
+	return lt.open()
+}
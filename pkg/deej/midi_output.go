@@ -0,0 +1,148 @@
+package deej
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// status byte of a MIDI control change message, before the channel is added to it
+const midiControlChange = 0xB0
+
+// midiSink is wherever MIDI messages end up, i.e. a MIDI port
+type midiSink interface {
+	sendControlChange(channel uint8, controller uint8, value uint8) error
+	Close() error
+}
+
+// rawMIDISink writes MIDI messages as-is to a raw MIDI device, such as /dev/snd/midiC1D0 on Linux
+// (or a virtual one created with the snd-virmidi module, which other MIDI software can connect to)
+type rawMIDISink struct {
+	device io.WriteCloser
+}
+
+func openRawMIDISink(path string) (*rawMIDISink, error) {
+	device, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open raw MIDI device: %w", err)
+	}
+
+	return &rawMIDISink{device: device}, nil
+}
+
+func (s *rawMIDISink) sendControlChange(channel uint8, controller uint8, value uint8) error {
+	if _, err := s.device.Write([]byte{midiControlChange | channel, controller, value}); err != nil {
+		return fmt.Errorf("write control change: %w", err)
+	}
+
+	return nil
+}
+
+func (s *rawMIDISink) Close() error {
+	return s.device.Close()
+}
+
+// midiOutput sends slider moves out as MIDI control changes, so faders can drive DAWs and other MIDI software
+type midiOutput struct {
+	deej   *Deej
+	logger *zap.SugaredLogger
+
+	sink midiSink
+	lock sync.Locker
+}
+
+func newMIDIOutput(deej *Deej, logger *zap.SugaredLogger) *midiOutput {
+	logger = logger.Named("midi")
+
+	m := &midiOutput{
+		deej:   deej,
+		logger: logger,
+		lock:   &sync.Mutex{},
+	}
+
+	logger.Debug("Created MIDI output instance")
+
+	return m
+}
+
+// start opens the given raw MIDI device and starts sending slider moves to it
+func (m *midiOutput) start(device string) error {
+	sink, err := openRawMIDISink(device)
+	if err != nil {
+		return fmt.Errorf("open MIDI device %s: %w", device, err)
+	}
+
+	m.logger.Infow("Sending slider moves over MIDI", "device", device)
+	m.run(sink)
+
+	return nil
+}
+
+// run starts sending slider moves to the given sink
+func (m *midiOutput) run(sink midiSink) {
+	m.lock.Lock()
+	m.sink = sink
+	m.lock.Unlock()
+
+	sliderEventsChannel := m.deej.serial.SubscribeToSliderMoveEvents()
+
+	go func() {
+		for event := range sliderEventsChannel {
+			m.handleSliderMove(event)
+		}
+	}()
+}
+
+func (m *midiOutput) stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.sink == nil {
+		return
+	}
+
+	if err := m.sink.Close(); err != nil {
+		m.logger.Warnw("Failed to close MIDI device", "error", err)
+	}
+
+	m.sink = nil
+	m.logger.Debug("MIDI output stopped")
+}
+
+func (m *midiOutput) handleSliderMove(event SliderMoveEvent) {
+
+	// previews are never applied to anything, and neither are moves of sliders without a controller
+	controller, ok := m.deej.config.MIDIOutput.Controllers[event.SliderID]
+	if event.Preview || !ok {
+		return
+	}
+
+	// control changes go from 0 to 127, with bipolar sliders centered around 64
+	value := event.PercentValue
+	if event.Bipolar {
+		value = (value + 1) / 2
+	}
+
+	scaledValue := uint8(math.Round(float64(value) * 127))
+	channel := uint8(m.deej.config.MIDIOutput.Channel - 1)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// we've been stopped in the meantime
+	if m.sink == nil {
+		return
+	}
+
+	if err := m.sink.sendControlChange(channel, uint8(controller), scaledValue); err != nil {
+		m.logger.Warnw("Failed to send control change",
+			"sliderID", event.SliderID,
+			"controller", controller,
+			"value", scaledValue,
+			"error", err)
+	}
+}
@@ -0,0 +1,102 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testControlChange is a single control change message, as received by testMIDISink
+type testControlChange struct {
+	channel    uint8
+	controller uint8
+	value      uint8
+}
+
+// testMIDISink hands every control change it's sent over to the test
+type testMIDISink struct {
+	messages chan testControlChange
+}
+
+func (s *testMIDISink) sendControlChange(channel uint8, controller uint8, value uint8) error {
+	s.messages <- testControlChange{channel: channel, controller: controller, value: value}
+	return nil
+}
+
+func (s *testMIDISink) Close() error {
+	return nil
+}
+
+func TestMIDIOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      CanonicalConfig
+		channel     int
+		controllers map[int]int
+		line        string
+		want        []testControlChange
+	}{
+		{
+			name:        "mapped sliders",
+			channel:     1,
+			controllers: map[int]int{0: 7, 1: 10},
+			line:        "0|512\r\n",
+			want:        []testControlChange{{channel: 0, controller: 7, value: 0}, {channel: 0, controller: 10, value: 64}},
+		},
+		{
+			name:        "other channel",
+			channel:     16,
+			controllers: map[int]int{0: 7},
+			line:        "1023\r\n",
+			want:        []testControlChange{{channel: 15, controller: 7, value: 127}},
+		},
+		{
+			name:        "unmapped slider",
+			channel:     1,
+			controllers: map[int]int{1: 10},
+			line:        "1023|1023\r\n",
+			want:        []testControlChange{{channel: 0, controller: 10, value: 127}},
+		},
+		{
+			name:        "bipolar slider",
+			config:      CanonicalConfig{BipolarSliders: []int{0}},
+			channel:     1,
+			controllers: map[int]int{0: 1},
+			line:        "0\r\n",
+			want:        []testControlChange{{channel: 0, controller: 1, value: 0}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.MIDIOutput.Channel = test.channel
+			config.MIDIOutput.Controllers = test.controllers
+
+			sio := newTestSerialIO(t, &config)
+			sink := &testMIDISink{messages: make(chan testControlChange, 10)}
+
+			m := newMIDIOutput(sio.deej, sio.logger)
+			m.run(sink)
+			defer m.stop()
+
+			if !sio.handleLine(sio.logger, test.line, newLineFormat("|", "\n", checksumNone)) {
+				t.Fatalf("handleLine(%q) wasn't accepted", test.line)
+			}
+
+			got := []testControlChange{}
+			for len(got) < len(test.want) {
+				select {
+				case message := <-sink.messages:
+					got = append(got, message)
+				case <-time.After(time.Second):
+					t.Fatalf("got control changes %+v, want %+v", got, test.want)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got control changes %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
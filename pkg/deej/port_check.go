@@ -0,0 +1,137 @@
+package deej
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// how many raw lines a port test keeps around as samples
+const testPortSampleSize = 10
+
+// opens the port for TestPort. swappable, much like SerialIO's openPort
+var testPortOpen = serial.Open
+
+// TestResult summarizes what a port test read from a serial port
+type TestResult struct {
+	Port     string
+	BaudRate uint
+	Duration time.Duration
+
+	LinesRead    int
+	MatchedLines int
+
+	// the first few lines read, as-is (whether they matched or not)
+	SampleLines []string
+
+	// the slider count most matched lines agreed on, or 0 if none matched
+	SliderCount int
+}
+
+// TestPort opens the given serial port, reads from it for the given duration and reports how much of what it read
+// looks like deej's default protocol. It doesn't need a config file or a deej instance, which makes it useful for
+// troubleshooting a board on its own. The port is always closed before this returns
+func TestPort(port string, baud uint, duration time.Duration) (TestResult, error) {
+	result := TestResult{
+		Port:     port,
+		BaudRate: baud,
+		Duration: duration,
+	}
+
+	minimumReadSize := 0
+	if util.Linux() {
+		minimumReadSize = 1
+	}
+
+	conn, err := testPortOpen(serial.OpenOptions{
+		PortName:        port,
+		BaudRate:        baud,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: uint(minimumReadSize),
+	})
+
+	if err != nil {
+		return result, fmt.Errorf("open serial port: %w", err)
+	}
+
+	// read on a separate goroutine, since reads block until there's data. closing the port ends them
+	linesChannel := make(chan string)
+	var readErr error
+
+	go func() {
+		defer close(linesChannel)
+
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				linesChannel <- line
+			}
+
+			if err != nil {
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	format := newLineFormat(defaultFieldSeparator, defaultLineTerminator, checksumNone)
+	sliderCounts := make(map[int]int)
+	timer := time.NewTimer(duration)
+
+	// set if reading stopped on its own, before the duration was up
+	readFailed := false
+
+	stillReading := true
+	for stillReading {
+		select {
+		case <-timer.C:
+			stillReading = false
+		case line, ok := <-linesChannel:
+			if !ok {
+				timer.Stop()
+				readFailed = true
+				stillReading = false
+				break
+			}
+
+			result.LinesRead++
+			if len(result.SampleLines) < testPortSampleSize {
+				result.SampleLines = append(result.SampleLines, line)
+			}
+
+			if format.pattern.MatchString(line) {
+				result.MatchedLines++
+				sliderCounts[len(strings.Split(strings.TrimSuffix(line, format.suffix), format.separator))]++
+			}
+		}
+	}
+
+	// closing the port ends the pending read, which lets the reading goroutine finish
+	closeErr := conn.Close()
+	for range linesChannel {
+	}
+
+	for sliderCount, lines := range sliderCounts {
+		if lines > sliderCounts[result.SliderCount] {
+			result.SliderCount = sliderCount
+		}
+	}
+
+	// read errors after we're done are just the port being closed
+	if readFailed {
+		return result, fmt.Errorf("read from serial port: %w", readErr)
+	}
+
+	if closeErr != nil {
+		return result, fmt.Errorf("close serial port: %w", closeErr)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package deej
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+)
+
+// testPipeConn is a testConn that, like a real port, ends pending reads when it's closed
+type testPipeConn struct {
+	testConn
+	reader *io.PipeReader
+}
+
+func (c *testPipeConn) Close() error {
+	return c.reader.Close()
+}
+
+func TestTestPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		readErr error
+		openErr error
+		want    TestResult
+		wantErr bool
+	}{
+		{
+			name:  "default protocol",
+			input: "512|300\r\n0|1023\r\n",
+			want: TestResult{
+				LinesRead:    2,
+				MatchedLines: 2,
+				SampleLines:  []string{"512|300\r\n", "0|1023\r\n"},
+				SliderCount:  2,
+			},
+		},
+		{
+			name:  "some garbage",
+			input: "hello\r\n512|300|1\r\n",
+			want: TestResult{
+				LinesRead:    2,
+				MatchedLines: 1,
+				SampleLines:  []string{"hello\r\n", "512|300|1\r\n"},
+				SliderCount:  3,
+			},
+		},
+		{
+			name: "quiet board",
+		},
+		{
+			name:    "read fails",
+			input:   "512|300\r\n",
+			readErr: errors.New("device disconnected"),
+			want: TestResult{
+				LinesRead:    1,
+				MatchedLines: 1,
+				SampleLines:  []string{"512|300\r\n"},
+				SliderCount:  2,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "open fails",
+			openErr: errors.New("no such port"),
+			wantErr: true,
+		},
+	}
+
+	defer func() { testPortOpen = serial.Open }()
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go func() {
+				writer.Write([]byte(test.input))
+
+				if test.readErr != nil {
+					writer.CloseWithError(test.readErr)
+				}
+			}()
+
+			testPortOpen = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				if test.openErr != nil {
+					return nil, test.openErr
+				}
+
+				return &testPipeConn{testConn: testConn{Reader: reader}, reader: reader}, nil
+			}
+
+			got, err := TestPort("COM4", 9600, 100*time.Millisecond)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("TestPort() returned %v, wantErr %v", err, test.wantErr)
+			}
+
+			want := test.want
+			want.Port = "COM4"
+			want.BaudRate = 9600
+			want.Duration = 100 * time.Millisecond
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got result %+v, want %+v", got, want)
+			}
+		})
+	}
+}
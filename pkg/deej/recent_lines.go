@@ -0,0 +1,84 @@
+package deej
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// how many of the most recent raw lines to keep around for diagnostics
+const recentLinesCapacity = 200
+
+// recentLines is a fixed-size ring buffer of the most recent raw lines read from serial. unlike the line
+// tracer, it's always on and never touches the disk unless asked to, which makes it cheap enough to keep
+// around for when a user reports something weird
+type recentLines struct {
+	lines []string
+	next  int
+	full  bool
+	lock  sync.Locker
+}
+
+func newRecentLines(capacity int) *recentLines {
+	return &recentLines{
+		lines: make([]string, capacity),
+		lock:  &sync.Mutex{},
+	}
+}
+
+func (rl *recentLines) add(line string) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	rl.lines[rl.next] = line
+	rl.next = (rl.next + 1) % len(rl.lines)
+
+	if rl.next == 0 {
+		rl.full = true
+	}
+}
+
+// snapshot returns the buffered lines, oldest first
+func (rl *recentLines) snapshot() []string {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if !rl.full {
+		result := make([]string, rl.next)
+		copy(result, rl.lines[:rl.next])
+
+		return result
+	}
+
+	result := make([]string, 0, len(rl.lines))
+	result = append(result, rl.lines[rl.next:]...)
+	result = append(result, rl.lines[:rl.next]...)
+
+	return result
+}
+
+// RecentLines returns the most recent raw lines read from serial (regardless of whether they were valid), oldest first
+func (sio *SerialIO) RecentLines() []string {
+	return sio.recentLines.snapshot()
+}
+
+// DumpRecentLines writes the most recent raw lines read from serial to the given file, one per line
+func (sio *SerialIO) DumpRecentLines(path string) error {
+	var builder strings.Builder
+
+	for _, line := range sio.RecentLines() {
+
+		// text mode lines still have their terminator, which we don't want to double up on
+		builder.WriteString(strings.TrimRight(line, "\r\n"))
+		builder.WriteString("\n")
+	}
+
+	if err := ioutil.WriteFile(path, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("write recent lines: %w", err)
+	}
+
+	sio.logger.Infow("Dumped recent serial lines", "path", path)
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecentLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name: "empty",
+			want: []string{},
+		},
+		{
+			name:  "not full yet",
+			lines: []string{"a", "b"},
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "exactly full",
+			lines: []string{"a", "b", "c"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "oldest lines are dropped",
+			lines: []string{"a", "b", "c", "d", "e"},
+			want:  []string{"c", "d", "e"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rl := newRecentLines(3)
+
+			for _, line := range test.lines {
+				rl.add(line)
+			}
+
+			if got := rl.snapshot(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got lines %q, want %q", got, test.want)
+			}
+		})
+	}
+}
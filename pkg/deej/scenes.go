@@ -0,0 +1,127 @@
+package deej
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// scenes are kept next to the internal config, so they survive restarts
+const scenesFilename = "scenes.json"
+
+// sceneStore keeps named snapshots of all slider values, persisted to a file
+type sceneStore struct {
+	logger *zap.SugaredLogger
+
+	path   string
+	scenes map[string][]float32
+	lock   sync.Locker
+}
+
+func newSceneStore(logger *zap.SugaredLogger, directory string) *sceneStore {
+	ss := &sceneStore{
+		logger: logger.Named("scenes"),
+		path:   path.Join(directory, scenesFilename),
+		scenes: make(map[string][]float32),
+		lock:   &sync.Mutex{},
+	}
+
+	// not having any scenes yet is perfectly normal
+	if !util.FileExists(ss.path) {
+		return ss
+	}
+
+	if err := ss.load(); err != nil {
+		ss.logger.Warnw("Failed to load scenes, starting without any", "path", ss.path, "error", err)
+	}
+
+	return ss
+}
+
+func (ss *sceneStore) load() error {
+	data, err := ioutil.ReadFile(ss.path)
+	if err != nil {
+		return fmt.Errorf("read scenes file: %w", err)
+	}
+
+	scenes := make(map[string][]float32)
+	if err := json.Unmarshal(data, &scenes); err != nil {
+		return fmt.Errorf("parse scenes file: %w", err)
+	}
+
+	ss.scenes = scenes
+	ss.logger.Debugw("Loaded scenes", "amount", len(scenes))
+
+	return nil
+}
+
+// save stores the given values as a scene and persists all scenes. assumes lock is held
+func (ss *sceneStore) save(name string, values []float32) error {
+	ss.scenes[name] = values
+
+	data, err := json.MarshalIndent(ss.scenes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal scenes: %w", err)
+	}
+
+	if err := util.EnsureDirExists(path.Dir(ss.path)); err != nil {
+		return fmt.Errorf("ensure scenes directory exists: %w", err)
+	}
+
+	if err := ioutil.WriteFile(ss.path, data, 0644); err != nil {
+		return fmt.Errorf("write scenes file: %w", err)
+	}
+
+	return nil
+}
+
+// SaveScene stores the current value of every slider under the given name, replacing any existing scene
+// with the same name. Scenes are persisted, so they can be restored after restarting deej
+func (sio *SerialIO) SaveScene(name string) error {
+	values := sio.snapshotValues()
+
+	sio.scenes.lock.Lock()
+	defer sio.scenes.lock.Unlock()
+
+	if err := sio.scenes.save(name, values); err != nil {
+		sio.logger.Warnw("Failed to save scene", "name", name, "error", err)
+		return fmt.Errorf("save scene %s: %w", name, err)
+	}
+
+	sio.logger.Infow("Saved scene", "name", name, "values", values)
+
+	return nil
+}
+
+// RestoreScene moves every slider in the given scene back to its saved value, emitting move events for all of them.
+// Sliders that hadn't reported a value when the scene was saved are left alone, as are locked and disabled ones
+func (sio *SerialIO) RestoreScene(name string) error {
+	sio.scenes.lock.Lock()
+	values, ok := sio.scenes.scenes[name]
+	sio.scenes.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("restore scene: no scene named %s", name)
+	}
+
+	sio.logger.Infow("Restoring scene", "name", name, "values", values)
+
+	for sliderID, value := range values {
+		if value < 0 {
+			continue
+		}
+
+		// scenes hold stored values, so bipolar sliders are restored without mapping them again
+		if err := sio.setStoredSliderValue(sliderID, value, false); err != nil {
+			sio.logger.Infow("Not restoring slider", "name", name, "sliderID", sliderID, "error", err)
+		}
+	}
+
+	return nil
+}
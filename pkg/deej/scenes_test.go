@@ -0,0 +1,102 @@
+package deej
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSceneRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     CanonicalConfig
+		locked     []int
+		saved      []float32
+		wantEvents []SliderMoveEvent
+		wantValues []float32
+	}{
+		{
+			name:  "regular sliders",
+			saved: []float32{0.25, 0.75},
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.25},
+				{SliderID: 1, PercentValue: 0.75},
+			},
+			wantValues: []float32{0.25, 0.75},
+		},
+		{
+			name:   "bipolar slider",
+			config: CanonicalConfig{BipolarSliders: []int{1}},
+			saved:  []float32{0.25, 0.75},
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.25},
+				{SliderID: 1, PercentValue: 0.5, Bipolar: true},
+			},
+			wantValues: []float32{0.25, 0.75},
+		},
+		{
+			name:       "locked slider is left alone",
+			locked:     []int{0},
+			saved:      []float32{0.25, 0.75},
+			wantEvents: []SliderMoveEvent{{SliderID: 1, PercentValue: 0.75}},
+			wantValues: []float32{0.5, 0.75},
+		},
+		{
+			name:       "slider without a value when saved",
+			saved:      []float32{-1, 0.75},
+			wantEvents: []SliderMoveEvent{{SliderID: 1, PercentValue: 0.75}},
+			wantValues: []float32{0.5, 0.75},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "deej-scenes")
+			if err != nil {
+				t.Fatalf("create temp dir: %v", err)
+			}
+
+			defer os.RemoveAll(directory)
+
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			sio.scenes = newSceneStore(sio.logger, directory)
+
+			setValues := func(values []float32) {
+				sio.valuesLock.Lock()
+				sio.resetSliders(len(values))
+				copy(sio.currentSliderPercentValues, values)
+				sio.valuesLock.Unlock()
+			}
+
+			setValues(test.saved)
+			if err := sio.SaveScene("test"); err != nil {
+				t.Fatalf("save scene: %v", err)
+			}
+
+			// a fresh store only knows what was persisted
+			sio.scenes = newSceneStore(sio.logger, directory)
+			setValues([]float32{0.5, 0.5})
+
+			for _, sliderID := range test.locked {
+				sio.SetSliderLocked(sliderID, true)
+			}
+
+			events := sio.SubscribeToSliderMoveEvents()
+			got := collectTestEvents(events, func() {
+				if err := sio.RestoreScene("test"); err != nil {
+					t.Errorf("restore scene: %v", err)
+				}
+			})
+
+			if !reflect.DeepEqual(got, test.wantEvents) {
+				t.Errorf("restoring delivered %+v, want %+v", got, test.wantEvents)
+			}
+
+			if values := sio.snapshotValues(); !reflect.DeepEqual(values, test.wantValues) {
+				t.Errorf("restored values %v, want %v", values, test.wantValues)
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jacobsa/go-serial/serial"
@@ -18,6 +20,10 @@ import (
 
 // SerialIO provides a deej-aware abstraction layer to managing serial I/O
 type SerialIO struct {
+
+	// accessed atomically, so it's kept first to guarantee 64-bit alignment on 32-bit platforms
+	bytesRead int64
+
 	comPort  string
 	baudRate uint
 
@@ -29,19 +35,133 @@ type SerialIO struct {
 	connOptions serial.OpenOptions
 	conn        io.ReadWriteCloser
 
+	// the read buffer size the current connection was opened with
+	readBufferSize int
+
+	// held while checking for and claiming a connection, so two callers can't both open the port
+	connectLock sync.Locker
+
+	// closed once the current connection reads its first valid line
+	firstLineChannel chan bool
+
+	// lines read before this don't emit any move events. guarded by valuesLock
+	startupDelayUntil time.Time
+
+	// holds the current *lineFormat. it can change while connected, so it's only ever swapped as a whole
+	lineFormat atomic.Value
+
 	lastKnownNumSliders        int
 	currentSliderPercentValues []float32
+	smoothedSliderValues       []float32
+	envelopeSliderValues       []float32
+	envelopeUpdatedAt          []time.Time
+	valuesLock                 sync.Locker
+
+	// overrides deej's verbose flag for serial logs only: 0 follows it, verboseOn and verboseOff override it.
+	// accessed atomically
+	verboseOverride int32
+
+	// runtime overrides for the config-provided list of locked sliders
+	lockedSliders map[int]bool
+
+	// predicates deciding whether specific sliders currently get to emit move events
+	sliderEnabledFuncs map[int]func() bool
 
 	sliderMoveConsumers []chan SliderMoveEvent
+	telemetryConsumers  []chan string
+	sliderMoveMailboxes []*sliderMailbox
+	sliderMoveQueues    []*sliderEventQueue
+	thresholdConsumers  []chan SliderThresholdEvent
+	railedConsumers     []chan SliderRailedEvent
+	peakTrackers        []*peakTracker
+	consumersLock       sync.Locker
+
+	// whether peak trackers should currently be running, which they only do while connected. guarded by consumersLock
+	peakTrackersRunning bool
+
+	triggers   *triggerTracker
+	rails      *railTracker
+	tickBuffer *sliderTickBuffer
+
+	releaseBuffer *sliderReleaseBuffer
+
+	testPatternStopChannel chan bool
+
+	hotplugStopChannel chan bool
+
+	// tells the hotplug watcher whether the configured port is there, replaceable for testing
+	portExists func(port string) bool
+
+	// opens the serial port, replaceable for testing
+	openPort func(options serial.OpenOptions) (io.ReadWriteCloser, error)
+
+	tracer      *lineTracer
+	recentLines *recentLines
+	stats       *serialStats
+	scenes      *sceneStore
 }
 
 // SliderMoveEvent represents a single slider move captured by deej
 type SliderMoveEvent struct {
 	SliderID     int
 	PercentValue float32
+
+	// preview events are only meant to be displayed, and shouldn't be applied to any volume
+	Preview bool
+
+	// bipolar events carry a value between -1.0 and 1.0 in PercentValue, and shouldn't be applied to any volume either
+	Bipolar bool
+}
+
+// countingReader keeps count of every byte read through it
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	atomic.AddInt64(cr.count, int64(n))
+
+	return n, err
 }
 
-var expectedLinePattern = regexp.MustCompile(`^\d{1,4}(\|\d{1,4})*\r\n$`)
+const (
+
+	// supported parity modes, as they appear in the config
+	parityNone = "none"
+	parityEven = "even"
+	parityOdd  = "odd"
+
+	// how long Start waits for the first read to fail before considering the connection successful
+	immediateReadFailureWindow = 200 * time.Millisecond
+
+	// serial verbose logging overrides
+	verboseOn  = 1
+	verboseOff = -1
+
+	// how far past the boundary between two steps (as a fraction of a step) a stepped slider has to go to change steps
+	sliderStepHysteresis = 0.1
+
+	// how often throughput is measured for Stats
+	throughputSampleInterval = time.Second
+
+	// how often to check whether we're receiving data without any valid lines in it
+	baudMismatchCheckInterval = 5 * time.Second
+)
+
+// errImmediateReadFailure means the port opened, but reading from it failed right away
+var errImmediateReadFailure = errors.New("serial: read failed right after connecting")
+
+// these are returned when trying to set the value of a slider that can't take it
+var (
+	ErrSliderLocked          = errors.New("serial: slider is locked")
+	ErrSliderDisabled        = errors.New("serial: slider is disabled")
+	ErrSliderValueOutOfRange = errors.New("serial: slider value out of range")
+)
+
+// these are suggested to the user when we detect a likely baud rate mismatch
+var commonBaudRates = []int{9600, 19200, 38400, 57600, 115200}
 
 // NewSerialIO creates a SerialIO instance that uses the provided deej
 // instance's connection info to establish communications with the arduino chip
@@ -54,7 +174,21 @@ func NewSerialIO(deej *Deej, logger *zap.SugaredLogger) (*SerialIO, error) {
 		stopChannel:         make(chan bool),
 		connected:           false,
 		conn:                nil,
+		connectLock:         &sync.Mutex{},
+		valuesLock:          &sync.Mutex{},
+		lockedSliders:       make(map[int]bool),
+		sliderEnabledFuncs:  make(map[int]func() bool),
 		sliderMoveConsumers: []chan SliderMoveEvent{},
+		consumersLock:       &sync.Mutex{},
+		triggers:            newTriggerTracker(),
+		rails:               newRailTracker(),
+		tickBuffer:          newSliderTickBuffer(),
+		releaseBuffer:       newSliderReleaseBuffer(),
+		recentLines:         newRecentLines(recentLinesCapacity),
+		stats:               newSerialStats(),
+		scenes:              newSceneStore(logger, internalConfigPath),
+		portExists:          portExists,
+		openPort:            serial.Open,
 	}
 
 	logger.Debug("Created serial i/o instance")
@@ -65,8 +199,11 @@ func NewSerialIO(deej *Deej, logger *zap.SugaredLogger) (*SerialIO, error) {
 	return sio, nil
 }
 
-// Start attempts to connect to our arduino chip
-func (sio *SerialIO) Start() error {
+// open opens the port with the current connection params and claims it as our connection, unless we're already
+// connected. it's the only part of connecting that has to be exclusive, so it holds the connect lock throughout
+func (sio *SerialIO) open() error {
+	sio.connectLock.Lock()
+	defer sio.connectLock.Unlock()
 
 	// don't allow multiple concurrent connections
 	if sio.connected {
@@ -82,21 +219,35 @@ func (sio *SerialIO) Start() error {
 		minimumReadSize = 1
 	}
 
+	// some adapters behave better with a different value, so let the user override it
+	if sio.deej.config.ConnectionInfo.MinimumReadSize >= 0 {
+		minimumReadSize = sio.deej.config.ConnectionInfo.MinimumReadSize
+	}
+
+	parity, err := parityMode(sio.deej.config.ConnectionInfo.Parity)
+	if err != nil {
+		return fmt.Errorf("determine parity mode: %w", err)
+	}
+
 	sio.connOptions = serial.OpenOptions{
 		PortName:        sio.deej.config.ConnectionInfo.COMPort,
 		BaudRate:        uint(sio.deej.config.ConnectionInfo.BaudRate),
 		DataBits:        8,
 		StopBits:        1,
+		ParityMode:      parity,
 		MinimumReadSize: uint(minimumReadSize),
 	}
 
+	sio.deej.config.AdvancedSerialOptions.mergeInto(&sio.connOptions)
+	sio.readBufferSize = sio.deej.config.ConnectionInfo.ReadBufferSize
+
 	sio.logger.Debugw("Attempting serial connection",
 		"comPort", sio.connOptions.PortName,
 		"baudRate", sio.connOptions.BaudRate,
-		"minReadSize", minimumReadSize)
+		"minReadSize", minimumReadSize,
+		"parity", sio.deej.config.ConnectionInfo.Parity)
 
-	var err error
-	sio.conn, err = serial.Open(sio.connOptions)
+	sio.conn, err = sio.openPort(sio.connOptions)
 	if err != nil {
 
 		// might need a user notification here, TBD
@@ -104,29 +255,220 @@ func (sio *SerialIO) Start() error {
 		return fmt.Errorf("open serial connection: %w", err)
 	}
 
+	sio.connected = true
+
+	return nil
+}
+
+// Start attempts to connect to our arduino chip
+func (sio *SerialIO) Start() error {
+	if err := sio.open(); err != nil {
+		return err
+	}
+
 	namedLogger := sio.logger.Named(strings.ToLower(sio.connOptions.PortName))
 
 	namedLogger.Infow("Connected", "conn", sio.conn)
-	sio.connected = true
+	sio.stats.markConnected()
+
+	firstLineChannel := make(chan bool)
+	sio.firstLineChannel = firstLineChannel
+
+	// some boards send garbage for a while after being reset by the connection, so give them a moment
+	if startupDelay := sio.deej.config.StartupDelay; startupDelay > 0 {
+		sio.valuesLock.Lock()
+		sio.startupDelayUntil = time.Now().Add(startupDelay)
+		sio.valuesLock.Unlock()
+
+		namedLogger.Debugw("Ignoring slider moves until the board settles", "startupDelay", startupDelay)
+
+		// once it's over, resync so the next line emits move events for all sliders
+		time.AfterFunc(startupDelay, func() {
+			sio.valuesLock.Lock()
+			sio.lastKnownNumSliders = 0
+			sio.valuesLock.Unlock()
+
+			namedLogger.Debug("Startup delay over, processing slider moves")
+		})
+	}
+
+	sio.lineFormat.Store(sio.lineFormatFromConfig())
+
+	// trace every processed line to a file, if enabled
+	if sio.deej.config.LineTrace.Path != "" {
+		tracer, err := newLineTracer(namedLogger, sio.deej.config.LineTrace.Path, sio.deej.config.LineTrace.MaxSize)
+		if err != nil {
+			namedLogger.Warnw("Failed to create line tracer, continuing without it", "error", err)
+		}
+
+		sio.tracer = tracer
+	}
+
+	// buffered, since Start may have stopped waiting for it by the time it's used
+	readFailedChannel := make(chan error, 1)
 
 	// read lines or await a stop
 	go func() {
-		connReader := bufio.NewReader(sio.conn)
-		lineChannel := sio.readLine(namedLogger, connReader)
+
+		// peaks only decay while we're connected
+		sio.startPeakTrackers()
+		defer sio.stopPeakTrackers()
+
+		connReader := bufio.NewReaderSize(countingReader{reader: sio.conn, count: &sio.bytesRead}, sio.readBufferSize)
+
+		// only one of these gets used, depending on the protocol (a nil channel never fires)
+		var lineChannel chan formattedLine
+		var frameChannel chan binaryFrame
+
+		// readers report the error they stopped on here. it's buffered so they can always exit,
+		// even when they only stopped because we closed the connection ourselves
+		readErrChannel := make(chan error, 1)
+
+		// closed once we stop, so readers don't block forever on a line we'll never take
+		readDoneChannel := make(chan bool)
+		defer close(readDoneChannel)
+
+		if sio.deej.config.Protocol == protocolBinary {
+			frameChannel = sio.readFrames(namedLogger, connReader, readErrChannel, readDoneChannel)
+		} else {
+			lineChannel = sio.readLine(namedLogger, connReader, readErrChannel, readDoneChannel)
+		}
+
+		// if enabled, release the port after a while without any valid lines (a nil channel never fires)
+		idleTimeout := sio.deej.config.IdleTimeout
+
+		var idleChannel <-chan time.Time
+		if idleTimeout > 0 {
+			idleChannel = time.After(idleTimeout)
+		}
+
+		// periodically make sure that if we're getting data, at least some of it makes sense
+		baudCheckTicker := time.NewTicker(baudMismatchCheckInterval)
+		defer baudCheckTicker.Stop()
+
+		// keep track of throughput for Stats, and log it if asked to (a nil channel never fires)
+		throughputTicker := time.NewTicker(throughputSampleInterval)
+		defer throughputTicker.Stop()
+
+		lastThroughputSample := sio.stats.sample(atomic.LoadInt64(&sio.bytesRead))
+
+		var throughputLogChannel <-chan time.Time
+		if logInterval := sio.deej.config.ThroughputLogInterval; logInterval > 0 {
+			throughputLogTicker := time.NewTicker(logInterval)
+			defer throughputLogTicker.Stop()
+
+			throughputLogChannel = throughputLogTicker.C
+		}
+
+		lastThroughputLogSample := lastThroughputSample
+
+		bytesAtLastBaudCheck := atomic.LoadInt64(&sio.bytesRead)
+		validSinceLastBaudCheck := false
+		warnedAboutBaudMismatch := false
+		validSinceConnecting := false
 
 		for {
+			valid := false
+
 			select {
 			case <-sio.stopChannel:
 				sio.close(namedLogger)
+				return
+			case <-idleChannel:
+				namedLogger.Infow("No slider activity for a while, disconnecting until resumed", "idleTimeout", idleTimeout)
+				sio.close(namedLogger)
+				return
+			case <-baudCheckTicker.C:
+				bytesRead := atomic.LoadInt64(&sio.bytesRead)
+
+				if bytesRead > bytesAtLastBaudCheck && !validSinceLastBaudCheck && !warnedAboutBaudMismatch {
+					sio.warnAboutBaudMismatch(namedLogger, bytesRead-bytesAtLastBaudCheck)
+					warnedAboutBaudMismatch = true
+				}
+
+				bytesAtLastBaudCheck = bytesRead
+				validSinceLastBaudCheck = false
+			case <-throughputTicker.C:
+				sample := sio.stats.sample(atomic.LoadInt64(&sio.bytesRead))
+				sio.stats.updateThroughput(sample.rates(lastThroughputSample))
+				lastThroughputSample = sample
+			case <-throughputLogChannel:
+				sample := sio.stats.sample(atomic.LoadInt64(&sio.bytesRead))
+				bytesPerSecond, linesPerSecond := sample.rates(lastThroughputLogSample)
+				lastThroughputLogSample = sample
+
+				namedLogger.Infow("Serial throughput",
+					"bytesPerSecond", bytesPerSecond,
+					"linesPerSecond", linesPerSecond)
+			case err := <-readErrChannel:
+				namedLogger.Warnw("Failed to read from serial, closing connection", "error", err)
+				sio.close(namedLogger)
+
+				// let Start know if this happened before the connection ever worked
+				if !validSinceConnecting {
+					readFailedChannel <- err
+				}
+
+				return
 			case line := <-lineChannel:
-				sio.handleLine(namedLogger, line)
+				valid = sio.handleLine(namedLogger, line.text, line.format)
+			case frame := <-frameChannel:
+				valid = sio.handleFrame(namedLogger, frame)
+			}
+
+			if valid {
+				sio.stats.markValidLine()
+
+				if !validSinceConnecting {
+					close(firstLineChannel)
+					validSinceConnecting = true
+				}
+
+				validSinceLastBaudCheck = true
+
+				if idleTimeout > 0 {
+					idleChannel = time.After(idleTimeout)
+				}
 			}
 		}
 	}()
 
+	// some flaky usb-serial chips open just fine, only to fail on the very first read. give the connection a
+	// moment to prove itself, so that's reported as a failure to connect rather than leaving us with a dead one
+	select {
+	case err := <-readFailedChannel:
+		return fmt.Errorf("%w: %v", errImmediateReadFailure, err)
+	case <-firstLineChannel:
+	case <-time.After(immediateReadFailureWindow):
+	}
+
 	return nil
 }
 
+// WaitForFirstLine blocks until the current connection reads its first valid line, or until the timeout
+// elapses. Start returns as soon as the port is open, so this is useful to avoid racing the first read
+func (sio *SerialIO) WaitForFirstLine(timeout time.Duration) error {
+	firstLineChannel := sio.firstLineChannel
+	if firstLineChannel == nil {
+		return errors.New("serial: not connected")
+	}
+
+	select {
+	case <-firstLineChannel:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("serial: no valid line read within %s", timeout)
+	}
+}
+
+// Connected returns whether there's currently an open serial connection
+func (sio *SerialIO) Connected() bool {
+	sio.connectLock.Lock()
+	defer sio.connectLock.Unlock()
+
+	return sio.connected
+}
+
 // Stop signals us to shut down our serial connection, if one is active
 func (sio *SerialIO) Stop() {
 	if sio.connected {
@@ -137,171 +479,1232 @@ func (sio *SerialIO) Stop() {
 	}
 }
 
-// SubscribeToSliderMoveEvents returns an unbuffered channel that receives
-// a sliderMoveEvent struct every time a slider moves
-func (sio *SerialIO) SubscribeToSliderMoveEvents() chan SliderMoveEvent {
-	ch := make(chan SliderMoveEvent)
-	sio.sliderMoveConsumers = append(sio.sliderMoveConsumers, ch)
+// CurrentValue returns the last known value of the given slider, or false if
+// that slider doesn't exist or hasn't reported a value since the last resync
+func (sio *SerialIO) CurrentValue(sliderID int) (float32, bool) {
+	sio.valuesLock.Lock()
+	defer sio.valuesLock.Unlock()
 
-	return ch
+	if sliderID < 0 || sliderID >= len(sio.currentSliderPercentValues) {
+		return 0, false
+	}
+
+	value := sio.currentSliderPercentValues[sliderID]
+
+	// this is the impossible value we reset sliders to
+	if value < 0 {
+		return 0, false
+	}
+
+	return value, true
 }
 
-func (sio *SerialIO) setupOnConfigReload() {
-	configReloadedChannel := sio.deej.config.SubscribeToChanges()
+// SetVerbose turns verbose logging on or off for serial activity only, regardless of whether deej itself
+// is running in verbose mode. Until it's called, serial logs follow deej's verbose flag
+func (sio *SerialIO) SetVerbose(verbose bool) {
+	override := int32(verboseOff)
+	if verbose {
+		override = verboseOn
+	}
 
-	const stopDelay = 50 * time.Millisecond
+	atomic.StoreInt32(&sio.verboseOverride, override)
+	sio.logger.Infow("Changed serial verbose logging", "verbose", verbose)
+}
 
-	go func() {
-		for {
-			select {
-			case <-configReloadedChannel:
+// verbose returns whether serial activity should be logged verbosely
+func (sio *SerialIO) verbose() bool {
+	switch atomic.LoadInt32(&sio.verboseOverride) {
+	case verboseOn:
+		return true
+	case verboseOff:
+		return false
+	default:
+		return sio.deej.Verbose()
+	}
+}
 
-				// make any config reload unset our slider number to ensure process volumes are being re-set
-				// (the next read line will emit SliderMoveEvent instances for all sliders)\
-				// this needs to happen after a small delay, because the session map will also re-acquire sessions
-				// whenever the config file is reloaded, and we don't want it to receive these move events while the map
-				// is still cleared. this is kind of ugly, but shouldn't cause any issues
-				go func() {
-					<-time.After(stopDelay)
-					sio.lastKnownNumSliders = 0
-				}()
+// SetSliderLocked locks or unlocks the given slider. Locked sliders still keep track of their
+// position, but don't emit move events - so unlocking one won't cause a jump in volume
+func (sio *SerialIO) SetSliderLocked(sliderID int, locked bool) {
+	sio.valuesLock.Lock()
+	defer sio.valuesLock.Unlock()
 
-				// if connection params have changed, attempt to stop and start the connection
-				if sio.deej.config.ConnectionInfo.COMPort != sio.connOptions.PortName ||
-					uint(sio.deej.config.ConnectionInfo.BaudRate) != sio.connOptions.BaudRate {
+	sio.logger.Infow("Changing slider lock state", "sliderID", sliderID, "locked", locked)
+	sio.lockedSliders[sliderID] = locked
+}
 
-					sio.logger.Info("Detected change in connection parameters, attempting to renew connection")
-					sio.Stop()
+// SetSliderEnabledFunc registers a predicate that's consulted whenever the given slider moves, i.e. to
+// only let it change an app's volume while that app is focused. While it returns false, the slider's
+// position is still tracked but no move events are emitted. The predicate is called on the read loop, so it
+// should be quick. A panicking predicate is logged and the slider treated as enabled. Passing nil removes it
+func (sio *SerialIO) SetSliderEnabledFunc(sliderID int, fn func() bool) {
+	sio.valuesLock.Lock()
+	defer sio.valuesLock.Unlock()
 
-					// let the connection close
-					<-time.After(stopDelay)
+	if fn == nil {
+		delete(sio.sliderEnabledFuncs, sliderID)
+		return
+	}
 
-					if err := sio.Start(); err != nil {
-						sio.logger.Warnw("Failed to renew connection after parameter change", "error", err)
-					} else {
-						sio.logger.Debug("Renewed connection successfully")
-					}
-				}
-			}
-		}
-	}()
+	sio.sliderEnabledFuncs[sliderID] = fn
 }
 
-func (sio *SerialIO) close(logger *zap.SugaredLogger) {
-	if err := sio.conn.Close(); err != nil {
-		logger.Warnw("Failed to close serial connection", "error", err)
-	} else {
-		logger.Debug("Serial connection closed")
+// SetSliderValue moves the given slider to the given value as if it was moved by hand. The value is in
+// the slider's own range, so bipolar sliders take -1.0 - 1.0 and all others 0.0 - 1.0. Same as with moves
+// read from the board, locked and disabled sliders can't be moved, and a false predicate drops the move.
+// Preview values are delivered to consumers flagged as such (e.g. to display a value while it's
+// being scrubbed) without being applied; a follow-up non-preview call commits the value
+func (sio *SerialIO) SetSliderValue(sliderID int, value float32, preview bool) error {
+	minValue := float32(0)
+	if sio.sliderBipolar(sliderID) {
+		minValue = -1
 	}
 
-	sio.conn = nil
-	sio.connected = false
+	if value < minValue || value > 1 {
+		return fmt.Errorf("set slider %d to %v: %w", sliderID, value, ErrSliderValueOutOfRange)
+	}
+
+	// bipolar sliders are stored like any other, it's only their events that are centered around 0
+	if minValue < 0 {
+		value = (value + 1) / 2
+	}
+
+	return sio.setStoredSliderValue(sliderID, value, preview)
 }
 
-func (sio *SerialIO) readLine(logger *zap.SugaredLogger, reader *bufio.Reader) chan string {
-	ch := make(chan string)
+// setStoredSliderValue moves the given slider to the given 0.0 - 1.0 value, as it would be stored
+func (sio *SerialIO) setStoredSliderValue(sliderID int, value float32, preview bool) error {
+	if !sio.sliderEnabled(sliderID) {
+		return fmt.Errorf("set slider %d: %w", sliderID, ErrSliderDisabled)
+	}
 
-	go func() {
-		for {
-			line, err := reader.ReadString('\n')
-			if err != nil {
+	sio.valuesLock.Lock()
 
-				if sio.deej.Verbose() {
-					logger.Warnw("Failed to read line from serial", "error", err, "line", line)
-				}
+	if sio.sliderLocked(sliderID) {
+		sio.valuesLock.Unlock()
+		return fmt.Errorf("set slider %d: %w", sliderID, ErrSliderLocked)
+	}
 
-				// just ignore the line, the read loop will stop after this
-				return
-			}
+	if !preview && sliderID >= 0 && sliderID < len(sio.currentSliderPercentValues) {
+		sio.currentSliderPercentValues[sliderID] = value
+	}
 
-			if sio.deej.Verbose() {
-				logger.Debugw("Read new line", "line", line)
-			}
+	sio.valuesLock.Unlock()
 
-			// deliver the line to the channel
-			ch <- line
-		}
-	}()
+	sio.logger.Debugw("Setting slider value", "sliderID", sliderID, "value", value, "preview", preview)
 
-	return ch
+	moveEvent := SliderMoveEvent{
+		SliderID:     sliderID,
+		PercentValue: value,
+		Preview:      preview,
+	}
+
+	if sio.sliderBipolar(sliderID) {
+		moveEvent.PercentValue = value*2 - 1
+		moveEvent.Bipolar = true
+	}
+
+	if moveEvents := sio.enabledMoveEvents(sio.logger, []SliderMoveEvent{moveEvent}); len(moveEvents) > 0 {
+		sio.deliverMoveEvents(moveEvents)
+	}
+
+	return nil
 }
 
-func (sio *SerialIO) handleLine(logger *zap.SugaredLogger, line string) {
+// Reset forgets everything deej knows about the sliders - their values, smoothing and trigger state - while
+// keeping the connection open. The next line is then treated like the first one after connecting, so all
+// sliders emit move events again. This is useful after reconfiguring the board without reconnecting to it
+func (sio *SerialIO) Reset() {
+	sio.logger.Info("Resetting slider state")
 
-	// this function receives an unsanitized line which is guaranteed to end with LF,
-	// but most lines will end with CRLF. it may also have garbage instead of
-	// deej-formatted values, so we must check for that! just ignore bad ones
-	if !expectedLinePattern.MatchString(line) {
+	sio.valuesLock.Lock()
+	sio.resetSliders(0)
+	sio.valuesLock.Unlock()
+
+	sio.triggers.reset()
+	sio.rails.reset()
+	sio.releaseBuffer.reset()
+}
+
+// InjectSliderMove feeds the given value into the given slider as if the board had reported it, passing
+// through the same smoothing, deadzone and curve processing as a real reading. Unlike SetSliderValue,
+// the result may be filtered out entirely (i.e. if it's too close to the slider's current value)
+func (sio *SerialIO) InjectSliderMove(sliderID int, value float32) {
+	if sliderID < 0 {
+		sio.logger.Warnw("Can't inject move for negative slider ID", "sliderID", sliderID)
 		return
 	}
 
-	// trim the suffix
-	line = strings.TrimSuffix(line, "\r\n")
+	if value < 0 {
+		value = 0
+	} else if value > 1 {
+		value = 1
+	}
 
-	// split on pipe (|), this gives a slice of numerical strings between "0" and "1023"
-	splitLine := strings.Split(line, "|")
-	numSliders := len(splitLine)
+	logger := sio.logger.Named("inject")
+	rawValue := int(math.Round(float64(value) * float64(sio.maxRawValue())))
 
-	// update our slider count, if needed - this will send slider move events for all
-	if numSliders != sio.lastKnownNumSliders {
-		logger.Infow("Detected sliders", "amount", numSliders)
-		sio.lastKnownNumSliders = numSliders
-		sio.currentSliderPercentValues = make([]float32, numSliders)
+	sio.valuesLock.Lock()
 
-		// reset everything to be an impossible value to force the slider move event later
-		for idx := range sio.currentSliderPercentValues {
-			sio.currentSliderPercentValues[idx] = -1.0
-		}
+	// same as in binary mode, injected sliders may not have been seen yet
+	if sliderID >= len(sio.currentSliderPercentValues) {
+		logger.Infow("Detected sliders", "amount", sliderID+1)
+		sio.resetSliders(sliderID + 1)
 	}
 
-	// for each slider:
-	moveEvents := []SliderMoveEvent{}
-	for sliderIdx, stringValue := range splitLine {
+	moveEvent, moved := sio.applyRawValue(logger, sliderID, rawValue)
 
-		// convert string values to integers ("1023" -> 1023)
-		number, _ := strconv.Atoi(stringValue)
+	sio.valuesLock.Unlock()
 
-		// turns out the first line could come out dirty sometimes (i.e. "4558|925|41|643|220")
-		// so let's check the first number for correctness just in case
-		if sliderIdx == 0 && number > 1023 {
-			sio.logger.Debugw("Got malformed line from serial, ignoring", "line", line)
-			return
+	if moved {
+		if moveEvents := sio.enabledMoveEvents(logger, []SliderMoveEvent{moveEvent}); len(moveEvents) > 0 {
+			sio.deliverMoveEvents(moveEvents)
 		}
+	}
+}
+
+// Resume reconnects after the connection was released due to inactivity. Since the port is closed
+// while idle, deej has no way of noticing slider activity by itself - something else has to call this
+func (sio *SerialIO) Resume() error {
+	sio.logger.Info("Resuming serial connection")
+	return sio.Start()
+}
 
-		// map the value from raw to a "dirty" float between 0 and 1 (e.g. 0.15451...)
-		dirtyFloat := float32(number) / 1023.0
+// SubscribeToSliderMoveEvents returns an unbuffered channel that receives
+// a sliderMoveEvent struct every time a slider moves
+func (sio *SerialIO) SubscribeToSliderMoveEvents() chan SliderMoveEvent {
+	ch := make(chan SliderMoveEvent)
 
-		// normalize it to an actual volume scalar between 0.0 and 1.0 with 2 points of precision
-		normalizedScalar := util.NormalizeScalar(dirtyFloat)
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
 
-		// if sliders are inverted, take the complement of 1.0
-		if sio.deej.config.InvertSliders {
-			normalizedScalar = 1 - normalizedScalar
-		}
+	sio.sliderMoveConsumers = append(sio.sliderMoveConsumers, ch)
+
+	return ch
+}
 
-		// check if it changes the desired state (could just be a jumpy raw slider value)
-		if util.SignificantlyDifferent(sio.currentSliderPercentValues[sliderIdx], normalizedScalar, sio.deej.config.NoiseReductionLevel) {
+// SubscribeToMixerState returns the current value of every slider along with a channel of subsequent moves, as
+// SubscribeToSliderMoveEvents would. Both are taken together, so no move can fall between the two - any move
+// not reflected in the returned values is guaranteed to arrive on the channel. Sliders that haven't reported
+// a value since the last (re)connection are -1
+func (sio *SerialIO) SubscribeToMixerState() ([]float32, chan SliderMoveEvent) {
 
-			// if it does, update the saved value and create a move event
-			sio.currentSliderPercentValues[sliderIdx] = normalizedScalar
+	// moves are computed while holding the values lock and delivered after releasing it, so subscribing
+	// while we hold it means every move after our snapshot is delivered to the new subscriber as well
+	sio.valuesLock.Lock()
+	defer sio.valuesLock.Unlock()
 
-			moveEvents = append(moveEvents, SliderMoveEvent{
-				SliderID:     sliderIdx,
-				PercentValue: normalizedScalar,
-			})
+	values := make([]float32, len(sio.currentSliderPercentValues))
+	copy(values, sio.currentSliderPercentValues)
 
-			if sio.deej.Verbose() {
-				logger.Debugw("Slider moved", "event", moveEvents[len(moveEvents)-1])
-			}
+	return values, sio.SubscribeToSliderMoveEvents()
+}
+
+// SubscribeToThresholdEvents returns an unbuffered channel that receives
+// a SliderThresholdEvent every time a slider crosses one of its configured triggers
+func (sio *SerialIO) SubscribeToThresholdEvents() chan SliderThresholdEvent {
+	ch := make(chan SliderThresholdEvent)
+
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.thresholdConsumers = append(sio.thresholdConsumers, ch)
+
+	return ch
+}
+
+// SubscribeToRailedSliders returns an unbuffered channel that receives a SliderRailedEvent whenever a slider
+// has been stuck at one of its extremes for longer than the configured rail warning delay
+func (sio *SerialIO) SubscribeToRailedSliders() chan SliderRailedEvent {
+	ch := make(chan SliderRailedEvent)
+
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.railedConsumers = append(sio.railedConsumers, ch)
+
+	return ch
+}
+
+// SubscribeToTelemetryLines returns an unbuffered channel that receives every line the board sends that isn't
+// a value line, i.e. LF-terminated debug prints when using a custom line terminator. Lines are delivered as-is
+func (sio *SerialIO) SubscribeToTelemetryLines() chan string {
+	ch := make(chan string)
+
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.telemetryConsumers = append(sio.telemetryConsumers, ch)
+
+	return ch
+}
+
+// OnSliderMove registers a callback that's invoked for every slider move, as an alternative to draining
+// a subscription channel. Callbacks run on a dedicated goroutine, and a panicking callback is logged
+// and recovered from without affecting deej or any other consumer
+func (sio *SerialIO) OnSliderMove(callback func(SliderMoveEvent)) {
+	sliderEventsChannel := sio.SubscribeToSliderMoveEvents()
+
+	go func() {
+		for event := range sliderEventsChannel {
+			sio.invokeSliderMoveCallback(callback, event)
 		}
-	}
+	}()
+}
 
-	// deliver move events if there are any, towards all potential consumers
-	if len(moveEvents) > 0 {
-		for _, consumer := range sio.sliderMoveConsumers {
-			for _, moveEvent := range moveEvents {
-				consumer <- moveEvent
-			}
+func (sio *SerialIO) invokeSliderMoveCallback(callback func(SliderMoveEvent), event SliderMoveEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			sio.logger.Errorw("Recovered from panic in slider move callback", "error", r, "event", event)
+		}
+	}()
+
+	callback(event)
+}
+
+// ConsumerCount returns the number of active slider move event subscriptions (including callbacks)
+func (sio *SerialIO) ConsumerCount() int {
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	return len(sio.sliderMoveConsumers)
+}
+
+func (sio *SerialIO) setupOnConfigReload() {
+	configReloadedChannel := sio.deej.config.SubscribeToChanges()
+
+	// renewing the connection can be slow, so it happens on its own goroutine to keep config reloads flowing.
+	// this channel holds at most one pending renewal, which makes bursts of reloads coalesce into a single one
+	renewConnectionChannel := make(chan bool, 1)
+
+	go func() {
+		for range renewConnectionChannel {
+			sio.renewConnection()
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-configReloadedChannel:
+
+				// make any config reload unset our slider number to ensure process volumes are being re-set
+				// (the next read line will emit SliderMoveEvent instances for all sliders)\
+				// this needs to happen after a small delay, because the session map will also re-acquire sessions
+				// whenever the config file is reloaded, and we don't want it to receive these move events while the map
+				// is still cleared. this is kind of ugly, but shouldn't cause any issues
+				go func() {
+					<-time.After(sio.deej.config.ReconnectPolicy.StopDelay)
+
+					sio.valuesLock.Lock()
+					sio.lastKnownNumSliders = 0
+					sio.valuesLock.Unlock()
+				}()
+
+				// a changed line format doesn't need a new connection, the reader picks it up from the next line
+				if current := sio.currentLineFormat(); current != nil && !current.matches(sio.lineFormatFromConfig()) {
+					sio.logger.Info("Line format changed, applying it from the next line")
+					sio.lineFormat.Store(sio.lineFormatFromConfig())
+				}
+
+				// if connection params have changed, schedule a connection renewal (unless one is already pending)
+				if sio.connectionParamsChanged() {
+					select {
+					case renewConnectionChannel <- true:
+					default:
+						sio.logger.Debug("Connection renewal already pending, not scheduling another")
+					}
+				}
+			}
+		}
+	}()
+}
+
+func (sio *SerialIO) lineFormatFromConfig() *lineFormat {
+	return newLineFormat(sio.deej.config.FieldSeparator, sio.deej.config.LineTerminator, sio.deej.config.Checksum)
+}
+
+// currentLineFormat returns the line format in use, or nil if we never connected
+func (sio *SerialIO) currentLineFormat() *lineFormat {
+	format, _ := sio.lineFormat.Load().(*lineFormat)
+	return format
+}
+
+func (sio *SerialIO) connectionParamsChanged() bool {
+	parity, _ := parityMode(sio.deej.config.ConnectionInfo.Parity)
+
+	return sio.deej.config.ConnectionInfo.COMPort != sio.connOptions.PortName ||
+		uint(sio.deej.config.ConnectionInfo.BaudRate) != sio.connOptions.BaudRate ||
+		parity != sio.connOptions.ParityMode ||
+		sio.deej.config.ConnectionInfo.ReadBufferSize != sio.readBufferSize ||
+		!sio.deej.config.AdvancedSerialOptions.matches(sio.connOptions)
+}
+
+// mergeInto applies the advanced options on top of the given ones, leaving the primary connection fields alone
+func (aso AdvancedSerialOptions) mergeInto(options *serial.OpenOptions) {
+	options.DataBits = aso.DataBits
+	options.StopBits = aso.StopBits
+	options.RTSCTSFlowControl = aso.RTSCTSFlowControl
+	options.InterCharacterTimeout = uint(aso.InterCharacterTimeout / time.Millisecond)
+	options.Rs485Enable = aso.RS485Enabled
+	options.Rs485RtsHighDuringSend = aso.RS485RTSHighDuringSend
+	options.Rs485RtsHighAfterSend = aso.RS485RTSHighAfterSend
+	options.Rs485RxDuringTx = aso.RS485RxDuringTx
+	options.Rs485DelayRtsBeforeSend = int(aso.RS485DelayRTSBeforeSend / time.Millisecond)
+	options.Rs485DelayRtsAfterSend = int(aso.RS485DelayRTSAfterSend / time.Millisecond)
+}
+
+// matches returns true if the given options already have these advanced options applied
+func (aso AdvancedSerialOptions) matches(options serial.OpenOptions) bool {
+	merged := options
+	aso.mergeInto(&merged)
+
+	return merged == options
+}
+
+// parityMode maps a parity name from the config to go-serial's parity mode
+func parityMode(parity string) (serial.ParityMode, error) {
+	switch parity {
+	case parityNone:
+		return serial.PARITY_NONE, nil
+	case parityEven:
+		return serial.PARITY_EVEN, nil
+	case parityOdd:
+		return serial.PARITY_ODD, nil
+	default:
+		return serial.PARITY_NONE, fmt.Errorf("unknown parity %q, expected one of %q, %q or %q",
+			parity, parityNone, parityEven, parityOdd)
+	}
+}
+
+func (sio *SerialIO) renewConnection() {
+
+	// a previous renewal may have already picked up the latest connection params
+	if !sio.connectionParamsChanged() {
+		sio.logger.Debug("Connection parameters already up to date, skipping renewal")
+		return
+	}
+
+	// go-serial can't change the baud rate of an open port, so even that means reopening it - which resets most
+	// arduino boards. it's worth pointing out, since it may come as a surprise for what looks like a small change
+	parity, _ := parityMode(sio.deej.config.ConnectionInfo.Parity)
+	if sio.deej.config.ConnectionInfo.COMPort == sio.connOptions.PortName && parity == sio.connOptions.ParityMode &&
+		sio.deej.config.ConnectionInfo.ReadBufferSize == sio.readBufferSize &&
+		sio.deej.config.AdvancedSerialOptions.matches(sio.connOptions) {
+		sio.logger.Infow("Serial backend can't change baud rate in place, reconnecting (this may reset the board)",
+			"baudRate", sio.deej.config.ConnectionInfo.BaudRate)
+	}
+
+	sio.logger.Info("Detected change in connection parameters, attempting to renew connection")
+	sio.Stop()
+
+	// let the connection close
+	<-time.After(sio.deej.config.ReconnectPolicy.StopDelay)
+
+	if err := sio.Start(); err != nil {
+		sio.logger.Warnw("Failed to renew connection after parameter change", "error", err)
+	} else {
+		sio.logger.Debug("Renewed connection successfully")
+	}
+}
+
+// warnAboutBaudMismatch lets the user know we're receiving data that never makes any sense,
+// which almost always means the configured baud rate doesn't match the one used by the board
+func (sio *SerialIO) warnAboutBaudMismatch(logger *zap.SugaredLogger, bytesRead int64) {
+	logger.Warnw("Received data from serial but none of it was valid, baud rate is likely mismatched",
+		"bytesRead", bytesRead,
+		"interval", baudMismatchCheckInterval,
+		"baudRate", sio.connOptions.BaudRate,
+		"commonBaudRates", commonBaudRates)
+
+	sio.deej.notifier.Notify(fmt.Sprintf("Can't understand %s!", sio.connOptions.PortName),
+		fmt.Sprintf("Got data that doesn't make sense. Make sure %s in your config matches your board (usually %d).",
+			configKeyBaudRate, defaultBaudRate))
+}
+
+func (sio *SerialIO) close(logger *zap.SugaredLogger) {
+	if err := sio.conn.Close(); err != nil {
+		logger.Warnw("Failed to close serial connection", "error", err)
+	} else {
+		logger.Debug("Serial connection closed")
+	}
+
+	sio.connectLock.Lock()
+	sio.conn = nil
+	sio.connected = false
+	sio.connectLock.Unlock()
+
+	if sio.tracer != nil {
+		if err := sio.tracer.close(); err != nil {
+			logger.Warnw("Failed to close line tracer", "error", err)
+		}
+
+		sio.tracer = nil
+	}
+}
+
+func (sio *SerialIO) readLine(logger *zap.SugaredLogger, reader *bufio.Reader, errChannel chan<- error,
+	doneChannel <-chan bool) chan formattedLine {
+
+	ch := make(chan formattedLine)
+
+	go func() {
+
+		// we may have connected in the middle of a line, and its tail could happen to form a valid-looking
+		// line with garbage values. drop everything up to the first delimiter so we start on a clean boundary
+		if discarded, err := sio.readBoundedLine(logger, reader, sio.currentLineFormat()); err != nil {
+			if sio.verbose() {
+				logger.Warnw("Failed to resynchronize with serial stream", "error", err, "line", discarded)
+			}
+
+			errChannel <- err
+			return
+		} else if sio.verbose() {
+			logger.Debugw("Discarded first line to resynchronize", "line", discarded)
+		}
+
+		for {
+
+			// the format may change between lines, but a line that's already being read finishes with the one it started with
+			format := sio.currentLineFormat()
+
+			line, err := sio.readBoundedLine(logger, reader, format)
+			if err != nil {
+
+				if sio.verbose() || sio.deej.config.LogSerialLines {
+					logger.Warnw("Failed to read line from serial", "error", err, "line", line)
+				}
+
+				// just ignore the line, the read loop will stop after this. we only error
+				// before finding the delimiter, so any complete line preceding the error has already
+				// been delivered by a previous iteration, and what's left here can only be a partial one
+				errChannel <- err
+				return
+			}
+
+			// anything that isn't a value line is just passed along to whoever's interested
+			if !format.isValueLine(line) {
+				sio.handleTelemetryLine(logger, line)
+				continue
+			}
+
+			if sio.verbose() {
+				logger.Debugw("Read new line", "line", line)
+			}
+
+			// deliver the line to the channel, unless the read loop stopped without waiting for us
+			select {
+			case ch <- formattedLine{text: line, format: format}:
+			case <-doneChannel:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// readBoundedLine reads a single line up to the configured terminator, much like ReadString would, but drops any line
+// that grows beyond the configured maximum length (i.e. when the board never sends a delimiter) and
+// resynchronizes on the next delimiter instead of buffering indefinitely
+func (sio *SerialIO) readBoundedLine(logger *zap.SugaredLogger, reader *bufio.Reader, format *lineFormat) (string, error) {
+	maxLineLength := sio.deej.config.MaxLineLength
+
+	var line []byte
+	discarding := false
+
+	for {
+		chunk, err := readSliceAny(reader, format.terminators)
+
+		// ReadSlice's result is only valid until the next read, so copy it over
+		if !discarding {
+			line = append(line, chunk...)
+
+			if len(line) > maxLineLength {
+				if sio.verbose() || sio.deej.config.LogSerialLines {
+					logger.Warnw("Line exceeded maximum length, discarding until next delimiter",
+						"maxLineLength", maxLineLength)
+				}
+
+				discarding = true
+				line = nil
+			}
+		}
+
+		// the reader's buffer filled up before we found a delimiter, keep going
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err != nil {
+			return string(line), err
+		}
+
+		// we just hit the end of a discarded line, the next one should be a clean one
+		if discarding {
+			discarding = false
+			continue
+		}
+
+		return string(line), nil
+	}
+}
+
+// handleTelemetryLine passes a non-value line along to telemetry consumers
+func (sio *SerialIO) handleTelemetryLine(logger *zap.SugaredLogger, line string) {
+	if sio.verbose() {
+		logger.Debugw("Read telemetry line", "line", line)
+	}
+
+	sio.recentLines.add(line)
+
+	sio.consumersLock.Lock()
+	consumers := sio.telemetryConsumers
+	sio.consumersLock.Unlock()
+
+	for _, consumer := range consumers {
+		consumer <- line
+	}
+}
+
+// handleLine processes a single line read from serial, and returns whether it was a valid one
+func (sio *SerialIO) handleLine(logger *zap.SugaredLogger, line string, format *lineFormat) bool {
+
+	// this function receives an unsanitized line which is guaranteed to end with the terminator
+	// (by default LF, but most lines will end with CRLF). it may also have garbage instead of
+	// deej-formatted values, so we must check for that! just ignore bad ones
+	if !format.pattern.MatchString(line) {
+		sio.recordLine(logger, lineTraceRecord{Line: line, DropReason: lineTraceDropReasonMalformed})
+		return false
+	}
+
+	rawLine := line
+
+	// trim the suffix
+	line = strings.TrimSuffix(line, format.suffix)
+
+	// verify and strip the checksum, if we're expecting one. corruption could make any of the values wrong.
+	// whether we are comes from the line's own format, so a config reload mid-line can't mix up the two
+	if format.checksum != checksumNone {
+		payload, ok := verifyChecksum(line, format.checksum)
+		if !ok {
+			failures := sio.stats.addChecksumFailure()
+
+			if sio.verbose() {
+				logger.Debugw("Got line with bad checksum, ignoring", "line", line, "failures", failures)
+			}
+
+			sio.recordLine(logger, lineTraceRecord{Line: rawLine, DropReason: lineTraceDropReasonChecksum})
+			return false
+		}
+
+		line = payload
+	}
+
+	// split on the separator (pipe by default), this gives a slice of numerical strings
+	// between "0" and "1023" (or "100" in percent mode)
+	splitLine := strings.Split(line, format.separator)
+	numSliders := len(splitLine)
+
+	// other goroutines may read slider values (or force a resync) while we're updating them
+	sio.valuesLock.Lock()
+
+	// update our slider count, if needed - this will send slider move events for all
+	if numSliders != sio.lastKnownNumSliders {
+		logger.Infow("Detected sliders", "amount", numSliders)
+		sio.resetSliders(numSliders)
+	}
+
+	maxRawValue := sio.maxRawValue()
+
+	// for each slider:
+	moveEvents := []SliderMoveEvent{}
+	for sliderIdx, stringValue := range splitLine {
+
+		// convert string values to integers ("1023" -> 1023)
+		number, _ := strconv.Atoi(stringValue)
+
+		// turns out the first line could come out dirty sometimes (i.e. "4558|925|41|643|220")
+		// so let's check the first number for correctness just in case
+		if sliderIdx == 0 && number > maxRawValue {
+			sio.valuesLock.Unlock()
+
+			sio.logger.Debugw("Got malformed line from serial, ignoring", "line", line)
+			sio.recordLine(logger, lineTraceRecord{Line: rawLine, DropReason: lineTraceDropReasonOutOfRange})
+			return false
+		}
+
+		if sio.deej.config.RailWarningDelay > 0 {
+			sio.rails.observe(sliderIdx, number, maxRawValue, sio.deej.config.RailWarningDelay)
+		}
+
+		if moveEvent, moved := sio.applyRawValue(logger, sliderIdx, number); moved {
+			moveEvents = append(moveEvents, moveEvent)
+		}
+	}
+
+	// while the board is still starting up, its readings are tracked but not acted upon
+	if sio.startingUp() {
+		moveEvents = []SliderMoveEvent{}
+	}
+
+	sio.valuesLock.Unlock()
+
+	moveEvents = sio.enabledMoveEvents(logger, moveEvents)
+
+	sio.recordLine(logger, lineTraceRecord{Line: rawLine, Accepted: true, Events: moveEvents})
+
+	// log every Nth accepted line if asked to - a cheaper way to keep an eye on things than verbose mode
+	acceptedLines := sio.stats.addAcceptedLine()
+	if sampleRate := sio.deej.config.LineLogSampleRate; sampleRate > 0 && acceptedLines%uint64(sampleRate) == 0 {
+		logger.Infow("Sampled serial line",
+			"line", line,
+			"sliders", numSliders,
+			"events", moveEvents,
+			"acceptedLines", acceptedLines)
+	}
+
+	// deliver move events if there are any, towards all potential consumers
+	if len(moveEvents) > 0 {
+		sio.deliverMoveEvents(moveEvents)
+	}
+
+	sio.reportRailedSliders(logger)
+
+	return true
+}
+
+// resetSliders sets up per-slider state for the given number of sliders. assumes valuesLock is held
+func (sio *SerialIO) resetSliders(numSliders int) {
+	sio.lastKnownNumSliders = numSliders
+	sio.currentSliderPercentValues = make([]float32, numSliders)
+	sio.smoothedSliderValues = make([]float32, numSliders)
+	sio.envelopeSliderValues = make([]float32, numSliders)
+	sio.envelopeUpdatedAt = make([]time.Time, numSliders)
+
+	// reset everything to be an impossible value to force the slider move event later.
+	// this also marks the smoothing filter as unseeded, so it starts from the first reading rather than 0
+	for idx := range sio.currentSliderPercentValues {
+		sio.currentSliderPercentValues[idx] = -1.0
+		sio.smoothedSliderValues[idx] = -1.0
+		sio.envelopeSliderValues[idx] = -1.0
+	}
+}
+
+// startingUp returns whether we're still within the startup delay. assumes valuesLock is held
+func (sio *SerialIO) startingUp() bool {
+	return time.Now().Before(sio.startupDelayUntil)
+}
+
+// maxRawValue returns the raw value that represents a slider at 100%, according to the configured raw mode
+func (sio *SerialIO) maxRawValue() int {
+	if sio.deej.config.RawMode == rawModePercent {
+		return 100
+	}
+
+	return 1023
+}
+
+// applyRawValue maps a slider's raw value to a volume scalar and saves it, returning a move event
+// if that's significantly different from the slider's previous value. assumes valuesLock is held
+func (sio *SerialIO) applyRawValue(logger *zap.SugaredLogger, sliderIdx int, number int) (SliderMoveEvent, bool) {
+
+	// sliders we've been told to stay away from are left alone entirely (they're still in the line trace)
+	if !sio.sliderEnabled(sliderIdx) {
+		return SliderMoveEvent{}, false
+	}
+
+	// correct the raw value for known hardware inaccuracies, if configured
+	number = sio.correctRawValue(sliderIdx, number)
+
+	// map the value from raw to a "dirty" float between 0 and 1 (e.g. 0.15451...)
+	dirtyFloat := float32(number) / float32(sio.maxRawValue())
+
+	// smooth out jitter if enabled, while letting larger moves through. noisy sliders can have their own factor
+	smoothingFactor := sio.deej.config.Smoothing.Factor
+	if sliderFactor, ok := sio.deej.config.SliderSmoothing[sliderIdx]; ok {
+		smoothingFactor = sliderFactor
+	}
+
+	if smoothingFactor > 0 {
+
+		// seed the filter with the first reading, otherwise the slider would swell up from 0 after connecting
+		if sio.smoothedSliderValues[sliderIdx] < 0 {
+			sio.smoothedSliderValues[sliderIdx] = dirtyFloat
+		}
+
+		dirtyFloat = util.SmoothScalar(sio.smoothedSliderValues[sliderIdx], dirtyFloat,
+			smoothingFactor, sio.deej.config.Smoothing.BypassThreshold)
+
+		sio.smoothedSliderValues[sliderIdx] = dirtyFloat
+	}
+
+	// follow the slider more or less eagerly depending on which way it's going, if configured
+	dirtyFloat = sio.applyEnvelope(sliderIdx, dirtyFloat)
+
+	// normalize it to an actual volume scalar between 0.0 and 1.0 with 2 points of precision
+	normalizedScalar := util.NormalizeScalar(dirtyFloat)
+
+	// bipolar sliders round to the nearest step instead, since rounding down would put a centered slider
+	// (i.e. 511 out of 1023) just left of center
+	if sio.sliderBipolar(sliderIdx) {
+		normalizedScalar = float32(math.Round(float64(dirtyFloat)*100) / 100)
+	}
+
+	// snap to the center detent (if there is one) when the slider's physically close enough to it
+	detent := sio.deej.config.CenterDetent
+	if detent.Width > 0 && math.Abs(float64(normalizedScalar-detent.Center)) <= float64(detent.Width) {
+		normalizedScalar = detent.Center
+	}
+
+	// if sliders are inverted, take the complement of 1.0. by default that happens before the slider's
+	// response curves, so they shape the inverted value - unless the curves are configured to come first
+	if sio.deej.config.CurveBeforeInvert {
+		normalizedScalar = sio.applyResponseCurves(sliderIdx, normalizedScalar)
+	}
+
+	if sio.deej.config.InvertSliders {
+		normalizedScalar = 1 - normalizedScalar
+	}
+
+	if !sio.deej.config.CurveBeforeInvert {
+		normalizedScalar = sio.applyResponseCurves(sliderIdx, normalizedScalar)
+	}
+
+	// snap to either end when close enough to it, so every slider can fully mute and fully max its targets
+	if railDeadzone := sio.deej.config.RailDeadzone; railDeadzone > 0 {
+		if normalizedScalar <= railDeadzone {
+			normalizedScalar = 0
+		} else if normalizedScalar >= 1-railDeadzone {
+			normalizedScalar = 1
+		}
+	}
+
+	// snap to one of this slider's steps, if it has them. the last value tells us which step it's currently on
+	if steps, ok := sio.deej.config.SliderSteps[sliderIdx]; ok {
+		normalizedScalar = util.QuantizeScalar(normalizedScalar, sio.currentSliderPercentValues[sliderIdx],
+			steps, sliderStepHysteresis)
+	}
+
+	// check if it changes the desired state (could just be a jumpy raw slider value).
+	// sliders with their own deadzone use it instead of the global noise reduction level
+	significant := util.SignificantlyDifferent(sio.currentSliderPercentValues[sliderIdx], normalizedScalar,
+		sio.deej.config.NoiseReductionLevel)
+
+	if deadzone, ok := sio.deej.config.SliderDeadzone[sliderIdx]; ok {
+		significant = util.SignificantlyDifferentBy(sio.currentSliderPercentValues[sliderIdx], normalizedScalar,
+			float64(deadzone))
+	}
+
+	// (unless every reading should be reported, changed or not)
+	if !significant && !sio.deej.config.NoDedup {
+		return SliderMoveEvent{}, false
+	}
+
+	// if it does, update the saved value and create a move event
+	sio.currentSliderPercentValues[sliderIdx] = normalizedScalar
+
+	// locked sliders keep track of where they are, but don't get to move anything
+	if sio.sliderLocked(sliderIdx) {
+		if sio.verbose() {
+			logger.Debugw("Locked slider moved, ignoring", "sliderID", sliderIdx, "value", normalizedScalar)
+		}
+
+		return SliderMoveEvent{}, false
+	}
+
+	moveEvent := SliderMoveEvent{
+		SliderID:     sliderIdx,
+		PercentValue: normalizedScalar,
+	}
+
+	// bipolar sliders are still tracked as 0.0 - 1.0 like any other, only their events are centered around 0
+	if sio.sliderBipolar(sliderIdx) {
+		moveEvent.PercentValue = normalizedScalar*2 - 1
+		moveEvent.Bipolar = true
+	}
+
+	if sio.verbose() {
+		logger.Debugw("Slider moved", "event", moveEvent)
+	}
+
+	return moveEvent, true
+}
+
+// applyResponseCurves applies the given slider's gamma and curve to its value, if it has them
+func (sio *SerialIO) applyResponseCurves(sliderIdx int, value float32) float32 {
+
+	// gamma above 1.0 gives finer control at low volumes, below 1.0 at high ones
+	if gamma, ok := sio.deej.config.SliderGamma[sliderIdx]; ok {
+		value = util.NormalizeScalar(float32(math.Pow(float64(value), float64(gamma))))
+	}
+
+	if curve, ok := sio.deej.config.SliderCurves[sliderIdx]; ok {
+		value = util.NormalizeScalar(curve.apply(value))
+	}
+
+	return value
+}
+
+// applyEnvelope smooths the given value with the slider's attack time constant when it's going up, and its release
+// time constant when it's going down. since readings don't arrive at a fixed rate, the smoothing is time-based
+// rather than per-reading. assumes valuesLock is held
+func (sio *SerialIO) applyEnvelope(sliderIdx int, value float32) float32 {
+	attack, hasAttack := sio.deej.config.SliderAttack[sliderIdx]
+	release, hasRelease := sio.deej.config.SliderRelease[sliderIdx]
+
+	// sliders without their own time constants use the global ones, if there are any
+	if !hasAttack && sio.deej.config.Smoothing.Attack > 0 {
+		attack, hasAttack = sio.deej.config.Smoothing.Attack, true
+	}
+
+	if !hasRelease && sio.deej.config.Smoothing.Release > 0 {
+		release, hasRelease = sio.deej.config.Smoothing.Release, true
+	}
+
+	if !hasAttack && !hasRelease {
+		return value
+	}
+
+	// with only one of them set, smooth symmetrically
+	if !hasAttack {
+		attack = release
+	} else if !hasRelease {
+		release = attack
+	}
+
+	now := time.Now()
+	previous := sio.envelopeSliderValues[sliderIdx]
+	elapsed := now.Sub(sio.envelopeUpdatedAt[sliderIdx])
+
+	sio.envelopeUpdatedAt[sliderIdx] = now
+
+	// start from the first reading, same as regular smoothing
+	if previous < 0 {
+		sio.envelopeSliderValues[sliderIdx] = value
+		return value
+	}
+
+	timeConstant := release
+	if value > previous {
+		timeConstant = attack
+	}
+
+	if timeConstant <= 0 {
+		sio.envelopeSliderValues[sliderIdx] = value
+		return value
+	}
+
+	// this is how far an exponential moving average gets within the elapsed time
+	alpha := float32(1 - math.Exp(-float64(elapsed)/float64(timeConstant)))
+	next := previous + alpha*(value-previous)
+
+	sio.envelopeSliderValues[sliderIdx] = next
+
+	return next
+}
+
+// correctRawValue applies the slider's gain and offset to the given raw value, keeping it within the valid range
+func (sio *SerialIO) correctRawValue(sliderIdx int, number int) int {
+	gain, hasGain := sio.deej.config.SliderGain[sliderIdx]
+	offset, hasOffset := sio.deej.config.SliderOffset[sliderIdx]
+
+	if !hasGain && !hasOffset {
+		return number
+	}
+
+	if !hasGain {
+		gain = 1
+	}
+
+	corrected := int(math.Round(float64(float32(number)*gain + offset)))
+
+	if corrected < 0 {
+		return 0
+	}
+
+	if maxRawValue := sio.maxRawValue(); corrected > maxRawValue {
+		return maxRawValue
+	}
+
+	return corrected
+}
+
+// assumes valuesLock is held
+func (sio *SerialIO) sliderLocked(sliderIdx int) bool {
+	if locked, ok := sio.lockedSliders[sliderIdx]; ok {
+		return locked
+	}
+
+	for _, lockedIdx := range sio.deej.config.LockedSliders {
+		if lockedIdx == sliderIdx {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sio *SerialIO) sliderEnabled(sliderIdx int) bool {
+	for _, disabledIdx := range sio.deej.config.DisabledSliders {
+		if disabledIdx == sliderIdx {
+			return false
+		}
+	}
+
+	if len(sio.deej.config.EnabledSliders) == 0 {
+		return true
+	}
+
+	for _, enabledIdx := range sio.deej.config.EnabledSliders {
+		if enabledIdx == sliderIdx {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enabledMoveEvents drops the move events of sliders whose predicate currently says no. predicates aren't ours,
+// so this must be called without holding valuesLock
+func (sio *SerialIO) enabledMoveEvents(logger *zap.SugaredLogger, moveEvents []SliderMoveEvent) []SliderMoveEvent {
+	enabledEvents := make([]SliderMoveEvent, 0, len(moveEvents))
+
+	for _, moveEvent := range moveEvents {
+		if !sio.invokeSliderEnabledFunc(moveEvent.SliderID) {
+			if sio.verbose() {
+				logger.Debugw("Slider moved while its predicate is false, ignoring", "event", moveEvent)
+			}
+
+			continue
+		}
+
+		enabledEvents = append(enabledEvents, moveEvent)
+	}
+
+	return enabledEvents
+}
+
+func (sio *SerialIO) invokeSliderEnabledFunc(sliderIdx int) (enabled bool) {
+	sio.valuesLock.Lock()
+	enabledFunc, ok := sio.sliderEnabledFuncs[sliderIdx]
+	sio.valuesLock.Unlock()
+
+	if !ok {
+		return true
+	}
+
+	// a broken predicate shouldn't be able to silence its slider for good
+	defer func() {
+		if r := recover(); r != nil {
+			sio.logger.Errorw("Recovered from panic in slider predicate, treating slider as enabled",
+				"error", r, "sliderID", sliderIdx)
+
+			enabled = true
+		}
+	}()
+
+	return enabledFunc()
+}
+
+func (sio *SerialIO) sliderBipolar(sliderIdx int) bool {
+	for _, bipolarIdx := range sio.deej.config.BipolarSliders {
+		if bipolarIdx == sliderIdx {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordLine traces the outcome of handling a line and, if asked to, logs it
+func (sio *SerialIO) recordLine(logger *zap.SugaredLogger, record lineTraceRecord) {
+	sio.recentLines.add(record.Line)
+
+	if sio.tracer != nil {
+		sio.tracer.trace(record)
+	}
+
+	// this is independent of verbose mode, to allow capturing just the serial stream
+	if sio.deej.config.LogSerialLines {
+		if record.Accepted {
+			logger.Infow("Accepted serial line", "line", record.Line)
+		} else {
+			logger.Infow("Rejected serial line", "line", record.Line, "reason", record.DropReason)
+		}
+	}
+}
+
+func (sio *SerialIO) deliverMoveEvents(moveEvents []SliderMoveEvent) {
+
+	// triggers are evaluated regardless of coalescing, so a quick flick past a threshold still fires them
+	sio.fireTriggers(moveEvents)
+
+	// if applying on release, sliders being dragged only send previews (so displays can still follow them).
+	// the value a slider is left at goes out once it's been still for the release window
+	if window := sio.deej.config.ApplyOnRelease; window > 0 {
+		previewEvents := make([]SliderMoveEvent, 0, len(moveEvents))
+		for _, moveEvent := range moveEvents {
+			if !moveEvent.Preview {
+				sio.releaseBuffer.put(moveEvent, window, func(releasedEvent SliderMoveEvent) {
+					sio.dispatchMoveEvents([]SliderMoveEvent{releasedEvent})
+				})
+
+				moveEvent.Preview = true
+			}
+
+			previewEvents = append(previewEvents, moveEvent)
+		}
+
+		sio.dispatchMoveEvents(previewEvents)
+		return
+	}
+
+	// if enabled, hold regular moves back until the next tick. previews go out right away,
+	// since they're for display only and a stale one must never replace a committed value
+	if tick := sio.deej.config.SliderEventTick; tick > 0 {
+		var immediateEvents, bufferedEvents []SliderMoveEvent
+		for _, moveEvent := range moveEvents {
+			if moveEvent.Preview {
+				immediateEvents = append(immediateEvents, moveEvent)
+			} else {
+				bufferedEvents = append(bufferedEvents, moveEvent)
+			}
+		}
+
+		if sio.tickBuffer.put(bufferedEvents) {
+			go sio.flushTickBuffer(tick)
+		}
+
+		moveEvents = immediateEvents
+	}
+
+	sio.dispatchMoveEvents(moveEvents)
+}
+
+// flushTickBuffer dispatches the tick buffer's contents once per tick, until a tick passes without any moves
+func (sio *SerialIO) flushTickBuffer(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		moveEvents := sio.tickBuffer.take()
+		if moveEvents == nil {
+			return
+		}
+
+		sio.dispatchMoveEvents(moveEvents)
+	}
+}
+
+func (sio *SerialIO) fireTriggers(moveEvents []SliderMoveEvent) {
+	if len(sio.deej.config.SliderTriggers) == 0 {
+		return
+	}
+
+	sio.consumersLock.Lock()
+	thresholdConsumers := sio.thresholdConsumers
+	sio.consumersLock.Unlock()
+
+	thresholdEvents := sio.triggers.evaluate(sio.deej.config.SliderTriggers,
+		sio.deej.config.TriggerHysteresis, moveEvents)
+
+	for _, thresholdEvent := range thresholdEvents {
+		sio.logger.Debugw("Slider crossed trigger threshold", "event", thresholdEvent)
+
+		for _, consumer := range thresholdConsumers {
+			consumer <- thresholdEvent
+		}
+	}
+}
+
+// reportRailedSliders warns about sliders that have just been found stuck at an extreme
+func (sio *SerialIO) reportRailedSliders(logger *zap.SugaredLogger) {
+	railedEvents := sio.rails.take()
+	if len(railedEvents) == 0 {
+		return
+	}
+
+	sio.consumersLock.Lock()
+	railedConsumers := sio.railedConsumers
+	sio.consumersLock.Unlock()
+
+	for _, railedEvent := range railedEvents {
+		logger.Warnw("Slider has been stuck at an extreme, its pot might be faulty",
+			"sliderID", railedEvent.SliderID,
+			"rawValue", railedEvent.RawValue,
+			"duration", railedEvent.Duration)
+
+		// slider indexes are zero-based, same as in the config file
+		sio.deej.notifier.Notify(fmt.Sprintf("Slider %d might be faulty!", railedEvent.SliderID),
+			fmt.Sprintf("It's been stuck at %d for %s. If you didn't leave it there, check its wiring.",
+				railedEvent.RawValue, railedEvent.Duration.Round(time.Second)))
+
+		for _, consumer := range railedConsumers {
+			consumer <- railedEvent
+		}
+	}
+}
+
+func (sio *SerialIO) dispatchMoveEvents(moveEvents []SliderMoveEvent) {
+	if len(moveEvents) == 0 {
+		return
+	}
+
+	// peak trackers coalesce on their own, so they never hold up delivery
+	sio.observePeaks(moveEvents)
+
+	coalesce := sio.deej.config.CoalesceSliderEvents
+	queueSize := sio.deej.config.SliderEventQueueSize
+
+	// take a snapshot of our consumers, so we don't hold the lock while blocking on any of them.
+	// mailboxes and queues (and their goroutines) are only set up once their mode is actually in use
+	sio.consumersLock.Lock()
+	consumers := sio.sliderMoveConsumers
+
+	if coalesce {
+		for len(sio.sliderMoveMailboxes) < len(consumers) {
+			consumer := consumers[len(sio.sliderMoveMailboxes)]
+			sio.sliderMoveMailboxes = append(sio.sliderMoveMailboxes, newSliderMailbox(consumer))
+		}
+	} else if queueSize > 0 {
+		for len(sio.sliderMoveQueues) < len(consumers) {
+			consumer := consumers[len(sio.sliderMoveQueues)]
+			sio.sliderMoveQueues = append(sio.sliderMoveQueues, newSliderEventQueue(consumer))
+		}
+	}
+
+	mailboxes := sio.sliderMoveMailboxes
+	queues := sio.sliderMoveQueues
+	sio.consumersLock.Unlock()
+
+	// when coalescing, only the latest value of each slider is kept until its consumer is ready for it
+	if coalesce {
+		for _, mailbox := range mailboxes {
+			for _, moveEvent := range moveEvents {
+				mailbox.put(moveEvent)
+			}
+		}
+
+		return
+	}
+
+	// with per-consumer queues, each consumer gets every event on its own goroutine (within the queue's limit)
+	if queueSize > 0 {
+		for idx, queue := range queues {
+			if dropped := queue.put(moveEvents, queueSize); dropped > 0 && sio.verbose() {
+				sio.logger.Debugw("Slider move consumer is falling behind, dropped events from its queue",
+					"consumer", idx,
+					"droppedEvents", dropped)
+			}
+		}
+
+		return
+	}
+
+	for _, consumer := range consumers {
+		for _, moveEvent := range moveEvents {
+			consumer <- moveEvent
 		}
 	}
 }
@@ -0,0 +1,68 @@
+package deej
+
+import (
+	"errors"
+	"time"
+)
+
+// StartHotplugWatcher checks for the configured serial port at the given interval, and connects as soon as it
+// appears (i.e. when the board gets plugged in). It only acts when the port goes from missing to present,
+// so it never interferes with an active connection or one that was released on purpose, such as when idle
+func (sio *SerialIO) StartHotplugWatcher(interval time.Duration) error {
+	if sio.hotplugStopChannel != nil {
+		sio.logger.Warn("Hotplug watcher already running, can't start another without stopping first")
+		return errors.New("serial: hotplug watcher already active")
+	}
+
+	sio.logger.Infow("Watching for the board to be plugged in", "interval", interval)
+
+	stopChannel := make(chan bool)
+	sio.hotplugStopChannel = stopChannel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// start off assuming it's missing, so that a board that's already there gets connected to
+		// if nothing else has connected to it by the first poll
+		wasPresent := false
+
+		for {
+			select {
+			case <-stopChannel:
+				sio.logger.Debug("Hotplug watcher stopped")
+				return
+			case <-ticker.C:
+				present := sio.portExists(sio.deej.config.ConnectionInfo.COMPort)
+
+				appeared := present && !wasPresent
+				wasPresent = present
+
+				// Start refuses to open a second connection, so it's fine if the first-time connection
+				// is retrying at the same time - this check just avoids logging about it needlessly
+				if !appeared || sio.Connected() {
+					continue
+				}
+
+				sio.logger.Infow("Serial port appeared, connecting", "comPort", sio.deej.config.ConnectionInfo.COMPort)
+
+				if err := sio.Start(); err != nil {
+					sio.logger.Warnw("Failed to connect after serial port appeared", "error", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopHotplugWatcher stops watching for the board to be plugged in, if we're watching for it
+func (sio *SerialIO) StopHotplugWatcher() {
+	if sio.hotplugStopChannel == nil {
+		sio.logger.Debug("No hotplug watcher running, nothing to stop")
+		return
+	}
+
+	sio.hotplugStopChannel <- true
+	sio.hotplugStopChannel = nil
+}
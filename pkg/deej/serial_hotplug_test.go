@@ -0,0 +1,83 @@
+package deej
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+)
+
+func TestHotplugWatcher(t *testing.T) {
+	tests := []struct {
+		name      string
+		present   []bool
+		wantOpens int32
+	}{
+		{
+			name:      "missing, then plugged in",
+			present:   []bool{false, false, true},
+			wantOpens: 1,
+		},
+		{
+			name:    "never plugged in",
+			present: []bool{false, false, false},
+		},
+		{
+			name:      "unplugged and plugged back in while connected",
+			present:   []bool{true, false, true},
+			wantOpens: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{}
+			config.ConnectionInfo.COMPort = "COM4"
+
+			sio := newTestSerialIO(t, config)
+
+			// the port list changes with every poll, and then stays the way it ended up
+			var polls int32
+			sio.portExists = func(port string) bool {
+				poll := int(atomic.AddInt32(&polls, 1)) - 1
+				if poll >= len(test.present) {
+					poll = len(test.present) - 1
+				}
+
+				return test.present[poll]
+			}
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			var opens int32
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				atomic.AddInt32(&opens, 1)
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.StartHotplugWatcher(time.Millisecond); err != nil {
+				t.Fatalf("start hotplug watcher: %v", err)
+			}
+
+			// connecting takes a while, so give it a chance to happen after the last change
+			deadline := time.Now().Add(time.Second)
+			for atomic.LoadInt32(&polls) <= int32(len(test.present))+1 {
+				if time.Now().After(deadline) {
+					t.Fatalf("hotplug watcher only polled %d times", atomic.LoadInt32(&polls))
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			sio.StopHotplugWatcher()
+			defer sio.Stop()
+
+			if got := atomic.LoadInt32(&opens); got != test.wantOpens {
+				t.Errorf("opened the port %d times, want %d", got, test.wantOpens)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrPortsSkipped is wrapped by the error ListPorts returns when some ports couldn't be read. The ports
+// that could be read are still returned alongside it, so callers can show those and just log the error
+var ErrPortsSkipped = errors.New("serial: some ports couldn't be listed")
+
+// PortInfo describes a serial port found on this machine. Everything but the name is only filled in when
+// the platform exposes it, which usually means the port belongs to a USB device
+type PortInfo struct {
+	Name        string
+	Description string
+
+	// USB vendor and product IDs, as 4-digit hex strings (e.g. "2341")
+	VID string
+	PID string
+
+	SerialNumber string
+}
+
+// ListPorts returns the serial ports currently available on this machine, sorted by name. It's meant
+// for letting users pick their board's port instead of typing it in. Any given candidates (such as the
+// configured port) that exist but weren't found otherwise are included too, since enumeration can miss
+// ports or fail outright. On failure, whatever could be found is still returned along with the error
+func ListPorts(candidates ...string) ([]PortInfo, error) {
+	ports, err := listPorts()
+	if ports == nil {
+		ports = []PortInfo{}
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "" || containsPort(ports, candidate) || !portExists(candidate) {
+			continue
+		}
+
+		ports = append(ports, PortInfo{Name: candidate})
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Name < ports[j].Name
+	})
+
+	if err != nil {
+		return ports, fmt.Errorf("list serial ports: %w", err)
+	}
+
+	return ports, nil
+}
+
+func containsPort(ports []PortInfo, port string) bool {
+	for _, portInfo := range ports {
+
+		// windows port names aren't case sensitive
+		if strings.EqualFold(portInfo.Name, port) {
+			return true
+		}
+	}
+
+	return false
+}
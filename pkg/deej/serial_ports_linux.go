@@ -0,0 +1,91 @@
+package deej
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the kinds of tty devices boards show up as. plain ttyS ports are left out, since most machines
+// report a few dozen of them whether or not anything's there
+var linuxSerialPortPatterns = []string{
+	"/dev/ttyUSB*",
+	"/dev/ttyACM*",
+	"/dev/ttyAMA*",
+	"/dev/rfcomm*",
+}
+
+// how far up from a tty's device to look for the USB device it belongs to. ttyACM devices are one level
+// below it (the interface), while usb-serial ttyUSB devices have another level in between
+const linuxUSBDeviceSearchDepth = 3
+
+func listPorts() ([]PortInfo, error) {
+	ports := []PortInfo{}
+
+	for _, pattern := range linuxSerialPortPatterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("glob %s: %w", pattern, err)
+		}
+
+		for _, path := range paths {
+			port := PortInfo{Name: path}
+			fillUSBPortInfo(&port, filepath.Base(path))
+
+			ports = append(ports, port)
+		}
+	}
+
+	return ports, nil
+}
+
+// portExists returns whether the given port's device is there, following symlinks such as the ones in
+// /dev/serial/by-id. unlike listPorts, this works for any kind of device, ttyS ports included
+func portExists(port string) bool {
+	devicePath, err := filepath.EvalSymlinks(port)
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(devicePath)
+
+	return err == nil
+}
+
+// fillUSBPortInfo looks the given tty up in sysfs, and fills in its USB descriptor info if it has any
+func fillUSBPortInfo(port *PortInfo, ttyName string) {
+	devicePath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/tty", ttyName, "device"))
+	if err != nil {
+		return
+	}
+
+	for depth := 0; depth < linuxUSBDeviceSearchDepth; depth++ {
+		devicePath = filepath.Dir(devicePath)
+
+		vid, ok := readSysfsAttribute(devicePath, "idVendor")
+		if !ok {
+			continue
+		}
+
+		port.VID = vid
+		port.PID, _ = readSysfsAttribute(devicePath, "idProduct")
+		port.SerialNumber, _ = readSysfsAttribute(devicePath, "serial")
+
+		manufacturer, _ := readSysfsAttribute(devicePath, "manufacturer")
+		product, _ := readSysfsAttribute(devicePath, "product")
+		port.Description = strings.TrimSpace(manufacturer + " " + product)
+
+		return
+	}
+}
+
+func readSysfsAttribute(devicePath string, attribute string) (string, bool) {
+	contents, err := ioutil.ReadFile(filepath.Join(devicePath, attribute))
+	if err != nil {
+		return "", false
+	}
+
+	return strings.TrimSpace(string(contents)), true
+}
@@ -0,0 +1,76 @@
+package deej
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestListPortsCandidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		want       []string
+	}{
+		{name: "no candidates", want: []string{}},
+		{name: "existing candidate", candidates: []string{"ttyACM0"}, want: []string{"ttyACM0"}},
+		{name: "missing candidate", candidates: []string{"ttyACM9"}, want: []string{}},
+		{name: "empty candidate", candidates: []string{""}, want: []string{}},
+		{
+			name:       "candidates are listed once, sorted",
+			candidates: []string{"ttyUSB0", "ttyACM0", "ttyUSB0"},
+			want:       []string{"ttyACM0", "ttyUSB0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "deej-ports")
+			if err != nil {
+				t.Fatalf("create temp dir: %v", err)
+			}
+
+			defer os.RemoveAll(directory)
+
+			// stand-ins for devices, since portExists only checks that they're there
+			for _, device := range []string{"ttyACM0", "ttyUSB0"} {
+				if err := ioutil.WriteFile(filepath.Join(directory, device), nil, 0600); err != nil {
+					t.Fatalf("create device: %v", err)
+				}
+			}
+
+			candidates := make([]string, len(test.candidates))
+			for idx, candidate := range test.candidates {
+				if candidate != "" {
+					candidate = filepath.Join(directory, candidate)
+				}
+
+				candidates[idx] = candidate
+			}
+
+			ports, err := ListPorts(candidates...)
+			if err != nil {
+				t.Fatalf("ListPorts() returned an unexpected error: %v", err)
+			}
+
+			if !sort.SliceIsSorted(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name }) {
+				t.Errorf("got unsorted ports %+v", ports)
+			}
+
+			// whatever real ports this machine has are listed too, so only look at ours
+			got := []string{}
+			for _, port := range ports {
+				if filepath.Dir(port.Name) == directory {
+					got = append(got, filepath.Base(port.Name))
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got candidate ports %q, want %q", got, test.want)
+			}
+		})
+	}
+}
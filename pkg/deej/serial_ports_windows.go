@@ -0,0 +1,183 @@
+package deej
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+
+	// every serial port windows currently knows about, regardless of what it belongs to
+	serialCommRegistryPath = `HARDWARE\DEVICEMAP\SERIALCOMM`
+
+	// USB devices, keyed by "VID_xxxx&PID_yyyy" and then by instance (usually their serial number)
+	usbEnumRegistryPath = `SYSTEM\CurrentControlSet\Enum\USB`
+)
+
+func listPorts() ([]PortInfo, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, serialCommRegistryPath, registry.QUERY_VALUE)
+	if err != nil {
+
+		// the key doesn't exist at all when there are no serial ports
+		if err == registry.ErrNotExist {
+			return []PortInfo{}, nil
+		}
+
+		return []PortInfo{}, fmt.Errorf("open serial ports registry key: %w", err)
+	}
+
+	defer key.Close()
+
+	// this can fail partway through, in which case we still go through the names we did get
+	valueNames, valueNamesErr := key.ReadValueNames(-1)
+	if valueNamesErr != nil && len(valueNames) == 0 {
+		return []PortInfo{}, fmt.Errorf("read serial ports registry values: %w", valueNamesErr)
+	}
+
+	// USB info is best-effort, since the port itself is what matters
+	usbPorts := usbPortInfo()
+
+	ports := []PortInfo{}
+	skipped := []string{}
+
+	for _, valueName := range valueNames {
+		portName, _, err := key.GetStringValue(valueName)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", valueName, err))
+			continue
+		}
+
+		port, ok := usbPorts[strings.ToUpper(portName)]
+		if !ok {
+			port = PortInfo{}
+		}
+
+		port.Name = portName
+		ports = append(ports, port)
+	}
+
+	if valueNamesErr != nil {
+		skipped = append(skipped, fmt.Sprintf("remaining registry values (%v)", valueNamesErr))
+	}
+
+	if len(skipped) > 0 {
+		return ports, fmt.Errorf("%w: %s", ErrPortsSkipped, strings.Join(skipped, ", "))
+	}
+
+	return ports, nil
+}
+
+// portExists returns whether windows currently has a device by the given name (i.e. "COM3"). unlike opening
+// the port to find out, this leaves a board that resets on connection alone
+func portExists(port string) bool {
+	name, err := windows.UTF16PtrFromString(port)
+	if err != nil {
+		return false
+	}
+
+	target := make([]uint16, windows.MAX_PATH)
+	_, err = windows.QueryDosDevice(name, &target[0], uint32(len(target)))
+
+	return err == nil
+}
+
+// usbPortInfo maps the COM port names of connected-at-some-point USB devices to their descriptor info
+func usbPortInfo() map[string]PortInfo {
+	result := make(map[string]PortInfo)
+
+	usbKey, err := registry.OpenKey(registry.LOCAL_MACHINE, usbEnumRegistryPath, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return result
+	}
+
+	defer usbKey.Close()
+
+	deviceIDs, err := usbKey.ReadSubKeyNames(-1)
+	if err != nil {
+		return result
+	}
+
+	for _, deviceID := range deviceIDs {
+		vid, pid, ok := parseUSBDeviceID(deviceID)
+		if !ok {
+			continue
+		}
+
+		deviceKey, err := registry.OpenKey(usbKey, deviceID, registry.ENUMERATE_SUB_KEYS)
+		if err != nil {
+			continue
+		}
+
+		instanceIDs, _ := deviceKey.ReadSubKeyNames(-1)
+		for _, instanceID := range instanceIDs {
+			port, ok := usbInstancePortInfo(deviceKey, instanceID)
+			if !ok {
+				continue
+			}
+
+			port.VID = vid
+			port.PID = pid
+			result[strings.ToUpper(port.Name)] = port
+		}
+
+		deviceKey.Close()
+	}
+
+	return result
+}
+
+func usbInstancePortInfo(deviceKey registry.Key, instanceID string) (PortInfo, bool) {
+	instanceKey, err := registry.OpenKey(deviceKey, instanceID, registry.QUERY_VALUE)
+	if err != nil {
+		return PortInfo{}, false
+	}
+
+	defer instanceKey.Close()
+
+	parametersKey, err := registry.OpenKey(instanceKey, "Device Parameters", registry.QUERY_VALUE)
+	if err != nil {
+		return PortInfo{}, false
+	}
+
+	defer parametersKey.Close()
+
+	portName, _, err := parametersKey.GetStringValue("PortName")
+	if err != nil {
+		return PortInfo{}, false
+	}
+
+	port := PortInfo{Name: portName}
+
+	// composite devices have instance IDs like "6&1a2b3c4d&0&0000" rather than a serial number
+	if !strings.Contains(instanceID, "&") {
+		port.SerialNumber = instanceID
+	}
+
+	if description, _, err := instanceKey.GetStringValue("FriendlyName"); err == nil {
+		port.Description = description
+	} else if description, _, err := instanceKey.GetStringValue("DeviceDesc"); err == nil {
+
+		// these usually look like "@oem12.inf,%description%;Arduino Uno", where only the last part is readable
+		port.Description = description[strings.LastIndex(description, ";")+1:]
+	}
+
+	return port, true
+}
+
+// parseUSBDeviceID extracts the vendor and product IDs from a device ID like "VID_2341&PID_0043"
+func parseUSBDeviceID(deviceID string) (string, string, bool) {
+	var vid, pid string
+
+	for _, part := range strings.Split(strings.ToUpper(deviceID), "&") {
+		if strings.HasPrefix(part, "VID_") {
+			vid = strings.TrimPrefix(part, "VID_")
+		} else if strings.HasPrefix(part, "PID_") {
+			pid = strings.TrimPrefix(part, "PID_")
+		}
+	}
+
+	return vid, pid, vid != "" && pid != ""
+}
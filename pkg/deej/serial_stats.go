@@ -0,0 +1,160 @@
+package deej
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SerialStats is a point-in-time summary of the serial connection, for diagnostics
+type SerialStats struct {
+	Connected bool
+
+	// zero if never connected. uptime is only counted while connected
+	ConnectedAt time.Time
+	Uptime      time.Duration
+
+	// zero if no valid line was read on the current connection
+	LastLineAt    time.Time
+	SinceLastLine time.Duration
+
+	BytesRead        int64
+	AcceptedLines    uint64
+	ChecksumFailures int
+
+	// measured over the last throughput sample (about a second), zero when disconnected
+	BytesPerSecond float64
+	LinesPerSecond float64
+}
+
+// serialStats keeps the counters behind SerialStats
+type serialStats struct {
+	connectedAt      time.Time
+	lastLineAt       time.Time
+	acceptedLines    uint64
+	checksumFailures int
+
+	// valid lines (or frames) read, across protocols
+	validLines uint64
+
+	bytesPerSecond float64
+	linesPerSecond float64
+
+	lock sync.Locker
+}
+
+// throughputSample is a point to measure throughput from
+type throughputSample struct {
+	at         time.Time
+	bytesRead  int64
+	validLines uint64
+}
+
+func newSerialStats() *serialStats {
+	return &serialStats{
+		lock: &sync.Mutex{},
+	}
+}
+
+func (ss *serialStats) markConnected() {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.connectedAt = time.Now()
+	ss.lastLineAt = time.Time{}
+	ss.bytesPerSecond = 0
+	ss.linesPerSecond = 0
+}
+
+func (ss *serialStats) markValidLine() {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.lastLineAt = time.Now()
+	ss.validLines++
+}
+
+// sample returns a point to measure throughput from, given the current byte count
+func (ss *serialStats) sample(bytesRead int64) throughputSample {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	return throughputSample{
+		at:         time.Now(),
+		bytesRead:  bytesRead,
+		validLines: ss.validLines,
+	}
+}
+
+// rates returns the bytes and valid lines per second between two samples
+func (ts throughputSample) rates(since throughputSample) (float64, float64) {
+	seconds := ts.at.Sub(since.at).Seconds()
+	if seconds <= 0 {
+		return 0, 0
+	}
+
+	return float64(ts.bytesRead-since.bytesRead) / seconds, float64(ts.validLines-since.validLines) / seconds
+}
+
+// updateThroughput sets the throughput reported by Stats
+func (ss *serialStats) updateThroughput(bytesPerSecond float64, linesPerSecond float64) {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.bytesPerSecond = bytesPerSecond
+	ss.linesPerSecond = linesPerSecond
+}
+
+// addAcceptedLine counts an accepted line and returns the new total
+func (ss *serialStats) addAcceptedLine() uint64 {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.acceptedLines++
+
+	return ss.acceptedLines
+}
+
+// addChecksumFailure counts a line with a bad checksum and returns the new total
+func (ss *serialStats) addChecksumFailure() int {
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.checksumFailures++
+
+	return ss.checksumFailures
+}
+
+// Stats returns a summary of the serial connection's state and activity
+func (sio *SerialIO) Stats() SerialStats {
+	connected := sio.Connected()
+
+	sio.stats.lock.Lock()
+	defer sio.stats.lock.Unlock()
+
+	now := time.Now()
+
+	stats := SerialStats{
+		Connected:        connected,
+		ConnectedAt:      sio.stats.connectedAt,
+		LastLineAt:       sio.stats.lastLineAt,
+		BytesRead:        atomic.LoadInt64(&sio.bytesRead),
+		AcceptedLines:    sio.stats.acceptedLines,
+		ChecksumFailures: sio.stats.checksumFailures,
+	}
+
+	if stats.Connected {
+		stats.BytesPerSecond = sio.stats.bytesPerSecond
+		stats.LinesPerSecond = sio.stats.linesPerSecond
+	}
+
+	if stats.Connected && !stats.ConnectedAt.IsZero() {
+		stats.Uptime = now.Sub(stats.ConnectedAt)
+	}
+
+	if !stats.LastLineAt.IsZero() {
+		stats.SinceLastLine = now.Sub(stats.LastLineAt)
+	}
+
+	return stats
+}
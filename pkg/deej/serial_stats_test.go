@@ -0,0 +1,173 @@
+package deej
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name          string
+		connect       bool
+		stop          bool
+		wantConnected bool
+		wantLastLine  bool
+		wantAccepted  uint64
+	}{
+		{name: "never connected"},
+		{name: "connected", connect: true, wantConnected: true, wantLastLine: true, wantAccepted: 2},
+		{name: "disconnected", connect: true, stop: true, wantLastLine: true, wantAccepted: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+
+			// the board sends a line to discard and two valid ones, then goes quiet
+			input := "0\r\n512\r\n1023\r\n"
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte(input))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if test.connect {
+				if err := sio.Start(); err != nil {
+					t.Fatalf("Start() returned an unexpected error: %v", err)
+				}
+
+				defer sio.Stop()
+
+				if err := sio.WaitForFirstLine(time.Second); err != nil {
+					t.Fatalf("WaitForFirstLine() returned an unexpected error: %v", err)
+				}
+
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			if test.stop {
+				sio.Stop()
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			stats := sio.Stats()
+
+			if stats.Connected != test.wantConnected {
+				t.Errorf("Connected = %v, want %v", stats.Connected, test.wantConnected)
+			}
+
+			if (stats.Uptime > 0) != test.wantConnected {
+				t.Errorf("Uptime = %v, want it counted only while connected", stats.Uptime)
+			}
+
+			if (stats.ConnectedAt.IsZero()) == test.connect {
+				t.Errorf("ConnectedAt = %v, want it set only after connecting", stats.ConnectedAt)
+			}
+
+			if (!stats.LastLineAt.IsZero()) != test.wantLastLine {
+				t.Errorf("LastLineAt = %v, want it set = %v", stats.LastLineAt, test.wantLastLine)
+			}
+
+			if stats.AcceptedLines != test.wantAccepted {
+				t.Errorf("AcceptedLines = %d, want %d", stats.AcceptedLines, test.wantAccepted)
+			}
+
+			if test.connect && stats.BytesRead != int64(len(input)) {
+				t.Errorf("BytesRead = %d, want %d", stats.BytesRead, len(input))
+			}
+		})
+	}
+}
+
+func TestThroughputSampleRates(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name      string
+		since     throughputSample
+		sample    throughputSample
+		wantBytes float64
+		wantLines float64
+	}{
+		{
+			name:      "over a second",
+			since:     throughputSample{at: start, bytesRead: 100, validLines: 10},
+			sample:    throughputSample{at: start.Add(time.Second), bytesRead: 300, validLines: 30},
+			wantBytes: 200,
+			wantLines: 20,
+		},
+		{
+			name:      "over half a second",
+			since:     throughputSample{at: start},
+			sample:    throughputSample{at: start.Add(500 * time.Millisecond), bytesRead: 100, validLines: 10},
+			wantBytes: 200,
+			wantLines: 20,
+		},
+		{
+			name:   "no time elapsed",
+			since:  throughputSample{at: start},
+			sample: throughputSample{at: start, bytesRead: 100, validLines: 10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bytesPerSecond, linesPerSecond := test.sample.rates(test.since)
+
+			if bytesPerSecond != test.wantBytes || linesPerSecond != test.wantLines {
+				t.Errorf("got %v bytes and %v lines per second, want %v and %v",
+					bytesPerSecond, linesPerSecond, test.wantBytes, test.wantLines)
+			}
+		})
+	}
+}
+
+func TestThroughputLogInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		logInterval time.Duration
+		wantLogged  bool
+	}{
+		{name: "off", wantLogged: false},
+		{name: "on", logInterval: 20 * time.Millisecond, wantLogged: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{ThroughputLogInterval: test.logInterval})
+
+			core, logs := observer.New(zapcore.InfoLevel)
+			sio.logger = zap.New(core).Sugar()
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte("0\r\n512\r\n"))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.Start(); err != nil {
+				t.Fatalf("Start() returned an unexpected error: %v", err)
+			}
+
+			defer sio.Stop()
+
+			time.Sleep(100 * time.Millisecond)
+
+			if logged := logs.FilterMessage("Serial throughput").Len() > 0; logged != test.wantLogged {
+				t.Errorf("logged throughput = %v, want %v", logged, test.wantLogged)
+			}
+		})
+	}
+}
@@ -0,0 +1,1725 @@
+package deej
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTestSerialIO creates a SerialIO on top of the given config, without connecting to anything
+func newTestSerialIO(t testing.TB, config *CanonicalConfig) *SerialIO {
+	t.Helper()
+
+	logger := zap.NewNop().Sugar()
+
+	if config.RawMode == "" {
+		config.RawMode = rawModeADC
+	}
+
+	if config.MaxLineLength == 0 {
+		config.MaxLineLength = defaultMaxLineLength
+	}
+
+	if config.FieldSeparator == "" {
+		config.FieldSeparator = defaultFieldSeparator
+		config.LineTerminator = defaultLineTerminator
+		config.Checksum = checksumNone
+	}
+
+	if config.ConnectionInfo.Parity == "" {
+		config.ConnectionInfo.Parity = defaultParity
+	}
+
+	config.logger = logger
+
+	d := &Deej{
+		logger: logger,
+		config: config,
+	}
+
+	sio, err := NewSerialIO(d, logger)
+	if err != nil {
+		t.Fatalf("create serial i/o: %v", err)
+	}
+
+	d.serial = sio
+
+	return sio
+}
+
+// handleTestLine feeds a single line to handleLine, and returns whether it was accepted along with
+// every move event it delivered to the given subscription
+func handleTestLine(sio *SerialIO, events chan SliderMoveEvent, line string, format *lineFormat) (bool, []SliderMoveEvent) {
+	var accepted bool
+
+	received := collectTestEvents(events, func() {
+		accepted = sio.handleLine(sio.logger, line, format)
+	})
+
+	return accepted, received
+}
+
+// collectTestEvents runs the given function, and returns every move event it delivered to the given subscription
+func collectTestEvents(events chan SliderMoveEvent, fn func()) []SliderMoveEvent {
+	done := make(chan bool)
+
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	// events are delivered on an unbuffered channel, so they've all been received by the time fn returns
+	received := []SliderMoveEvent{}
+	for {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-done:
+			return received
+		}
+	}
+}
+
+// testConn stands in for a serial connection, reading from the given reader and keeping everything written to it
+type testConn struct {
+	io.Reader
+
+	written bytes.Buffer
+	lock    sync.Mutex
+}
+
+func (c *testConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.written.Write(p)
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) writtenString() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.written.String()
+}
+
+func TestReadBoundedLine(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		format        *lineFormat
+		maxLineLength int
+		bufferSize    int
+		want          []string
+	}{
+		{
+			name:          "lines within the limit",
+			input:         "512|300\r\n0|1023\r\n",
+			format:        newLineFormat("|", "\n", checksumNone),
+			maxLineLength: 1024,
+			want:          []string{"512|300\r\n", "0|1023\r\n"},
+		},
+		{
+			name:          "oversized line is dropped up to its delimiter",
+			input:         strings.Repeat("9", 40) + "\r\n512|300\r\n",
+			format:        newLineFormat("|", "\n", checksumNone),
+			maxLineLength: 16,
+			want:          []string{"512|300\r\n"},
+		},
+		{
+			name:          "oversized line longer than the reader's buffer",
+			input:         strings.Repeat("1|", 100) + "1\r\n512|300\r\n",
+			format:        newLineFormat("|", "\n", checksumNone),
+			maxLineLength: 32,
+			bufferSize:    16,
+			want:          []string{"512|300\r\n"},
+		},
+		{
+			name:          "line exactly at the limit",
+			input:         "1023|1023\r\n",
+			format:        newLineFormat("|", "\n", checksumNone),
+			maxLineLength: len("1023|1023\r\n"),
+			want:          []string{"1023|1023\r\n"},
+		},
+		{
+			name:          "custom terminator still splits on LF",
+			input:         "1,2;debug print\n3,4;",
+			format:        newLineFormat(",", ";", checksumNone),
+			maxLineLength: 1024,
+			want:          []string{"1,2;", "debug print\n", "3,4;"},
+		},
+		{
+			name:          "oversized line with a custom terminator",
+			input:         strings.Repeat("5", 40) + ";1,2;",
+			format:        newLineFormat(",", ";", checksumNone),
+			maxLineLength: 16,
+			want:          []string{"1,2;"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{MaxLineLength: test.maxLineLength})
+
+			bufferSize := test.bufferSize
+			if bufferSize == 0 {
+				bufferSize = defaultReadBufferSize
+			}
+
+			reader := bufio.NewReaderSize(strings.NewReader(test.input), bufferSize)
+
+			got := []string{}
+			for {
+				line, err := sio.readBoundedLine(sio.logger, reader, test.format)
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Fatalf("readBoundedLine returned an unexpected error: %v", err)
+				}
+
+				got = append(got, line)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got lines %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHandleLine(t *testing.T) {
+	xorLine := func(payload string) string {
+		return fmt.Sprintf("%s*%02X\r\n", payload, xorChecksum([]byte(payload)))
+	}
+
+	tests := []struct {
+		name         string
+		config       CanonicalConfig
+		format       *lineFormat
+		line         string
+		wantAccepted bool
+		wantEvents   []SliderMoveEvent
+	}{
+		{
+			name:         "default format",
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "0|512|1023\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0},
+				{SliderID: 1, PercentValue: 0.5},
+				{SliderID: 2, PercentValue: 1},
+			},
+		},
+		{
+			name:   "default format requires CRLF",
+			format: newLineFormat("|", "\n", checksumNone),
+			line:   "0|512|1023\n",
+		},
+		{
+			name:   "garbage",
+			format: newLineFormat("|", "\n", checksumNone),
+			line:   "hello there\r\n",
+		},
+		{
+			name:   "dirty first value",
+			format: newLineFormat("|", "\n", checksumNone),
+			line:   "4558|925|41\r\n",
+		},
+		{
+			name:         "comma separator",
+			format:       newLineFormat(",", "\n", checksumNone),
+			line:         "1023,0\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 1},
+				{SliderID: 1, PercentValue: 0},
+			},
+		},
+		{
+			name:   "comma separator rejects pipes",
+			format: newLineFormat(",", "\n", checksumNone),
+			line:   "1023|0\r\n",
+		},
+		{
+			name:         "valid checksum",
+			format:       newLineFormat("|", "\n", checksumXOR),
+			line:         xorLine("512|1023"),
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.5},
+				{SliderID: 1, PercentValue: 1},
+			},
+		},
+		{
+			name:   "bad checksum",
+			format: newLineFormat("|", "\n", checksumXOR),
+			line:   "512|1023*00\r\n",
+		},
+		{
+			name:   "missing checksum",
+			format: newLineFormat("|", "\n", checksumXOR),
+			line:   "512|1023\r\n",
+		},
+		{
+			name:         "percent mode",
+			config:       CanonicalConfig{RawMode: rawModePercent},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "0|50|100\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0},
+				{SliderID: 1, PercentValue: 0.5},
+				{SliderID: 2, PercentValue: 1},
+			},
+		},
+		{
+			name:   "percent mode rejects ADC readings",
+			config: CanonicalConfig{RawMode: rawModePercent},
+			format: newLineFormat("|", "\n", checksumNone),
+			line:   "512|1023\r\n",
+		},
+		{
+			name:         "just inside the center detent",
+			config:       centerDetentTestConfig(0.5, 0.05),
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "553\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.5}},
+		},
+		{
+			name:         "just outside the center detent",
+			config:       centerDetentTestConfig(0.5, 0.05),
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "573\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.56}},
+		},
+		{
+			name:         "slider gamma",
+			config:       CanonicalConfig{SliderGamma: map[int]float32{0: 2}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "512|512\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.25},
+				{SliderID: 1, PercentValue: 0.5},
+			},
+		},
+		{
+			name:         "bipolar sliders",
+			config:       CanonicalConfig{BipolarSliders: []int{0, 1, 2}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "0|511|1023\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: -1, Bipolar: true},
+				{SliderID: 1, PercentValue: 0, Bipolar: true},
+				{SliderID: 2, PercentValue: 1, Bipolar: true},
+			},
+		},
+		{
+			name:         "only enabled sliders",
+			config:       CanonicalConfig{EnabledSliders: []int{0, 2}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "256|512|1023\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.25},
+				{SliderID: 2, PercentValue: 1},
+			},
+		},
+		{
+			name:         "disabled sliders",
+			config:       CanonicalConfig{DisabledSliders: []int{1}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "256|512|1023\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.25},
+				{SliderID: 2, PercentValue: 1},
+			},
+		},
+		{
+			name:         "disabled wins over enabled",
+			config:       CanonicalConfig{EnabledSliders: []int{0, 1}, DisabledSliders: []int{1}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "256|512|1023\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.25}},
+		},
+		{
+			name: "slider curve",
+			config: CanonicalConfig{SliderCurves: map[int]sliderCurve{
+				0: {{Input: 0, Output: 0}, {Input: 0.5, Output: 0.2}, {Input: 1, Output: 1}},
+			}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "512|1023\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.2},
+				{SliderID: 1, PercentValue: 1},
+			},
+		},
+		{
+			name: "slider gain and offset",
+			config: CanonicalConfig{
+				SliderGain:   map[int]float32{1: 1.1, 2: 2},
+				SliderOffset: map[int]float32{0: -20, 2: -100},
+			},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "20|950|562\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0},
+				{SliderID: 1, PercentValue: 1},
+				{SliderID: 2, PercentValue: 1},
+			},
+		},
+		{
+			name:         "slider gain and offset within range",
+			config:       CanonicalConfig{SliderGain: map[int]float32{0: 2}, SliderOffset: map[int]float32{0: -100}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "306\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.5}},
+		},
+		{
+			name:         "rail deadzone",
+			config:       CanonicalConfig{RailDeadzone: 0.03},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "20|1010|512\r\n",
+			wantAccepted: true,
+			wantEvents: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0},
+				{SliderID: 1, PercentValue: 1},
+				{SliderID: 2, PercentValue: 0.5},
+			},
+		},
+		{
+			name: "inverted, then curved",
+			config: CanonicalConfig{InvertSliders: true, SliderCurves: map[int]sliderCurve{
+				0: {{Input: 0, Output: 0}, {Input: 0.5, Output: 0.2}, {Input: 1, Output: 1}},
+			}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "256\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.6}},
+		},
+		{
+			name: "curved, then inverted",
+			config: CanonicalConfig{InvertSliders: true, CurveBeforeInvert: true, SliderCurves: map[int]sliderCurve{
+				0: {{Input: 0, Output: 0}, {Input: 0.5, Output: 0.2}, {Input: 1, Output: 1}},
+			}},
+			format:       newLineFormat("|", "\n", checksumNone),
+			line:         "256\r\n",
+			wantAccepted: true,
+			wantEvents:   []SliderMoveEvent{{SliderID: 0, PercentValue: 0.9}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			accepted, got := handleTestLine(sio, events, test.line, test.format)
+			if accepted != test.wantAccepted {
+				t.Errorf("handleLine(%q) returned %v, want %v", test.line, accepted, test.wantAccepted)
+			}
+
+			want := test.wantEvents
+			if want == nil {
+				want = []SliderMoveEvent{}
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("handleLine(%q) delivered %+v, want %+v", test.line, got, want)
+			}
+		})
+	}
+}
+
+func centerDetentTestConfig(center float32, width float32) CanonicalConfig {
+	config := CanonicalConfig{}
+	config.CenterDetent.Center = center
+	config.CenterDetent.Width = width
+
+	return config
+}
+
+func TestSliderMoveDeliverySurvivesPanickingCallbacks(t *testing.T) {
+	var nilMap map[int]int
+
+	tests := []struct {
+		name  string
+		panic func()
+	}{
+		{
+			name:  "string",
+			panic: func() { panic("oops") },
+		},
+		{
+			name:  "error",
+			panic: func() { panic(errors.New("oops")) },
+		},
+		{
+			name:  "runtime error",
+			panic: func() { nilMap[0] = 1 },
+		},
+	}
+
+	for _, test := range tests {
+
+		// the callbacks' goroutines outlive the subtest, so they need a copy of their own
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			format := newLineFormat("|", "\n", checksumNone)
+
+			// one callback that always panics, one that should keep working regardless,
+			// and a plain subscription standing in for the rest of deej
+			var panickingCalls int32
+			sio.OnSliderMove(func(SliderMoveEvent) {
+				atomic.AddInt32(&panickingCalls, 1)
+				test.panic()
+			})
+
+			healthyEvents := make(chan SliderMoveEvent, 10)
+			sio.OnSliderMove(func(event SliderMoveEvent) {
+				healthyEvents <- event
+			})
+
+			events := sio.SubscribeToSliderMoveEvents()
+
+			lines := []string{"0\r\n", "1023\r\n"}
+			for _, line := range lines {
+
+				// the read loop keeps accepting lines and delivering them after a callback panicked
+				accepted, got := handleTestLine(sio, events, line, format)
+				if !accepted || len(got) != 1 {
+					t.Fatalf("handleLine(%q) returned %v and delivered %+v after a callback panicked", line, accepted, got)
+				}
+			}
+
+			for idx := range lines {
+				select {
+				case <-healthyEvents:
+				case <-time.After(time.Second):
+					t.Fatalf("healthy callback only got %d of %d events", idx, len(lines))
+				}
+			}
+
+			// the panicking callback keeps getting called too, so its goroutine survived
+			deadline := time.Now().Add(time.Second)
+			for atomic.LoadInt32(&panickingCalls) < int32(len(lines)) {
+				if time.Now().After(deadline) {
+					t.Fatalf("panicking callback only got %d of %d events", atomic.LoadInt32(&panickingCalls), len(lines))
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestSliderLocking(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        CanonicalConfig
+		lockAtRuntime bool
+	}{
+		{name: "locked by config", config: CanonicalConfig{LockedSliders: []int{0}}},
+		{name: "locked at runtime", lockAtRuntime: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			if test.lockAtRuntime {
+				sio.SetSliderLocked(0, true)
+			}
+
+			// slider 0 keeps track of where it is while locked, but doesn't move anything
+			_, got := handleTestLine(sio, events, "0|0\r\n", format)
+			_, moved := handleTestLine(sio, events, "512|512\r\n", format)
+			got = append(got, moved...)
+
+			// once unlocked, it only moves once it's actually moved - not to catch up on what it missed
+			sio.SetSliderLocked(0, false)
+
+			_, moved = handleTestLine(sio, events, "512|1023\r\n", format)
+			got = append(got, moved...)
+
+			_, moved = handleTestLine(sio, events, "0|1023\r\n", format)
+			got = append(got, moved...)
+
+			want := []SliderMoveEvent{
+				{SliderID: 1, PercentValue: 0},
+				{SliderID: 1, PercentValue: 0.5},
+				{SliderID: 1, PercentValue: 1},
+				{SliderID: 0, PercentValue: 0},
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got events %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSetVerbose(t *testing.T) {
+	tests := []struct {
+		name        string
+		deejVerbose bool
+		setVerbose  []bool
+		want        bool
+	}{
+		{name: "follows deej when quiet", want: false},
+		{name: "follows deej when verbose", deejVerbose: true, want: true},
+		{name: "turned on", setVerbose: []bool{true}, want: true},
+		{name: "turned off while deej is verbose", deejVerbose: true, setVerbose: []bool{false}, want: false},
+		{name: "last call wins", setVerbose: []bool{true, false}, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			sio.deej.verbose = test.deejVerbose
+
+			for _, verbose := range test.setVerbose {
+				sio.SetVerbose(verbose)
+			}
+
+			if got := sio.verbose(); got != test.want {
+				t.Errorf("verbose() = %v, want %v", got, test.want)
+			}
+
+			// deej itself isn't affected either way
+			if sio.deej.Verbose() != test.deejVerbose {
+				t.Errorf("deej verbose changed to %v", sio.deej.Verbose())
+			}
+		})
+	}
+}
+
+func TestSliderEnabledFunc(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    func(sio *SerialIO) bool
+		wantEvents int
+	}{
+		{
+			name:       "enabled",
+			enabled:    func(*SerialIO) bool { return true },
+			wantEvents: 1,
+		},
+		{
+			name:       "disabled",
+			enabled:    func(*SerialIO) bool { return false },
+			wantEvents: 0,
+		},
+		{
+			name:       "panicking predicate counts as enabled",
+			enabled:    func(*SerialIO) bool { panic("oops") },
+			wantEvents: 1,
+		},
+		{
+			name: "predicate calling back into SerialIO",
+			enabled: func(sio *SerialIO) bool {
+				sio.SetSliderLocked(1, false)
+				return true
+			},
+			wantEvents: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			events := sio.SubscribeToSliderMoveEvents()
+
+			sio.SetSliderEnabledFunc(0, func() bool { return test.enabled(sio) })
+
+			result := make(chan []SliderMoveEvent)
+			go func() {
+				_, got := handleTestLine(sio, events, "512\r\n", newLineFormat("|", "\n", checksumNone))
+				result <- got
+			}()
+
+			select {
+			case got := <-result:
+				if len(got) != test.wantEvents {
+					t.Errorf("delivered %+v, want %d events", got, test.wantEvents)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("handleLine deadlocked calling the predicate")
+			}
+		})
+	}
+}
+
+func TestSliderSmoothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CanonicalConfig
+		lines  []string
+		want   []float32
+	}{
+		{
+			name:   "small move is smoothed",
+			config: smoothingTestConfig(0.5, 0.2),
+			lines:  []string{"1023\r\n", "900\r\n"},
+			want:   []float32{1, 0.9},
+		},
+		{
+			name:   "large move bypasses smoothing",
+			config: smoothingTestConfig(0.5, 0.2),
+			lines:  []string{"0\r\n", "1023\r\n"},
+			want:   []float32{0, 1},
+		},
+		{
+			name:   "first reading seeds the filter",
+			config: smoothingTestConfig(0.9, 0),
+			lines:  []string{"1023\r\n"},
+			want:   []float32{1},
+		},
+		{
+			name:   "slider's own smoothing factor",
+			config: CanonicalConfig{SliderSmoothing: map[int]float32{0: 0.5}},
+			lines:  []string{"1023\r\n", "900\r\n"},
+			want:   []float32{1, 0.93},
+		},
+		{
+			name:   "slider's own deadzone",
+			config: CanonicalConfig{SliderDeadzone: map[int]float32{0: 0.1}},
+			lines:  []string{"512\r\n", "563\r\n", "665\r\n"},
+			want:   []float32{0.5, 0.65},
+		},
+		{
+			name:   "slow attack holds back rises",
+			config: CanonicalConfig{SliderAttack: map[int]time.Duration{0: time.Hour}},
+			lines:  []string{"0\r\n", "1023\r\n", "0\r\n"},
+			want:   []float32{0},
+		},
+		{
+			name:   "slow release holds back falls",
+			config: CanonicalConfig{SliderRelease: map[int]time.Duration{0: time.Hour}},
+			lines:  []string{"1023\r\n", "0\r\n", "1023\r\n"},
+			want:   []float32{1},
+		},
+		{
+			name: "slow release doesn't hold back rises",
+			config: CanonicalConfig{
+				SliderAttack:  map[int]time.Duration{0: 0},
+				SliderRelease: map[int]time.Duration{0: time.Hour},
+			},
+			lines: []string{"0\r\n", "1023\r\n", "0\r\n"},
+			want:  []float32{0, 1},
+		},
+		{
+			name:   "global attack applies to both directions on its own",
+			config: envelopeTestConfig(time.Hour, 0),
+			lines:  []string{"0\r\n", "1023\r\n", "0\r\n"},
+			want:   []float32{0},
+		},
+		{
+			name: "slider's own attack overrides the global one",
+			config: func() CanonicalConfig {
+				config := envelopeTestConfig(0, time.Hour)
+				config.SliderAttack = map[int]time.Duration{0: 0}
+
+				return config
+			}(),
+			lines: []string{"0\r\n", "1023\r\n", "0\r\n"},
+			want:  []float32{0, 1},
+		},
+		{
+			name: "slider's own release overrides the global one",
+			config: func() CanonicalConfig {
+				config := envelopeTestConfig(time.Hour, time.Hour)
+				config.SliderRelease = map[int]time.Duration{0: 0}
+
+				return config
+			}(),
+			lines: []string{"1023\r\n", "0\r\n", "1023\r\n"},
+			want:  []float32{1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			got := []float32{}
+			for _, line := range test.lines {
+				_, moved := handleTestLine(sio, events, line, format)
+				for _, event := range moved {
+					got = append(got, event.PercentValue)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got values %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func smoothingTestConfig(factor float32, bypassThreshold float32) CanonicalConfig {
+	config := CanonicalConfig{}
+	config.Smoothing.Factor = factor
+	config.Smoothing.BypassThreshold = bypassThreshold
+
+	return config
+}
+
+func envelopeTestConfig(attack time.Duration, release time.Duration) CanonicalConfig {
+	config := CanonicalConfig{}
+	config.Smoothing.Attack = attack
+	config.Smoothing.Release = release
+
+	return config
+}
+
+func TestSliderSteps(t *testing.T) {
+	fiveSteps := map[int]int{0: 5}
+
+	tests := []struct {
+		name   string
+		config CanonicalConfig
+		lines  []string
+		want   []float32
+	}{
+		{
+			name:   "snaps to the nearest step",
+			config: CanonicalConfig{SliderSteps: fiveSteps},
+			lines:  []string{"0\r\n", "260\r\n", "700\r\n"},
+			want:   []float32{0, 0.25, 0.75},
+		},
+		{
+			name:   "stays on its step until past the boundary",
+			config: CanonicalConfig{SliderSteps: fiveSteps},
+			lines:  []string{"256\r\n", "399\r\n", "430\r\n"},
+			want:   []float32{0.25, 0.5},
+		},
+		{
+			name:   "other sliders aren't affected",
+			config: CanonicalConfig{SliderSteps: map[int]int{1: 5}},
+			lines:  []string{"300\r\n"},
+			want:   []float32{0.29},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			got := []float32{}
+			for _, line := range test.lines {
+				_, moved := handleTestLine(sio, events, line, format)
+				for _, event := range moved {
+					got = append(got, event.PercentValue)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got values %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLineLogSampleRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleRate int
+		want       int
+	}{
+		{name: "off", sampleRate: 0, want: 0},
+		{name: "every line", sampleRate: 1, want: 6},
+		{name: "every third line", sampleRate: 3, want: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{LineLogSampleRate: test.sampleRate})
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			core, logs := observer.New(zapcore.InfoLevel)
+			sio.logger = zap.New(core).Sugar()
+
+			for _, line := range []string{"0\r\n", "205\r\n", "410\r\n", "614\r\n", "819\r\n", "1023\r\n"} {
+				handleTestLine(sio, events, line, format)
+			}
+
+			if got := logs.FilterMessage("Sampled serial line").Len(); got != test.want {
+				t.Errorf("got %d sampled lines, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInjectSliderMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CanonicalConfig
+		values []float32
+		want   []SliderMoveEvent
+	}{
+		{
+			name:   "moves",
+			values: []float32{0.25, 1},
+			want:   []SliderMoveEvent{{SliderID: 1, PercentValue: 0.25}, {SliderID: 1, PercentValue: 1}},
+		},
+		{
+			name:   "too close to the current value",
+			values: []float32{0.5, 0.51},
+			want:   []SliderMoveEvent{{SliderID: 1, PercentValue: 0.5}},
+		},
+		{
+			name:   "through the slider's curves",
+			config: CanonicalConfig{SliderGamma: map[int]float32{1: 2}},
+			values: []float32{0.5},
+			want:   []SliderMoveEvent{{SliderID: 1, PercentValue: 0.25}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			got := collectTestEvents(events, func() {
+				for _, value := range test.values {
+					sio.InjectSliderMove(1, value)
+				}
+			})
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got events %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CanonicalConfig
+		reset  bool
+		line   string
+		want   []float32
+	}{
+		{
+			name: "unchanged value without a reset",
+			line: "512|1023\r\n",
+			want: []float32{},
+		},
+		{
+			name:  "unchanged value after a reset",
+			reset: true,
+			line:  "512|1023\r\n",
+			want:  []float32{0.5, 1},
+		},
+		{
+			name:   "smoothing is seeded again after a reset",
+			config: smoothingTestConfig(0.9, 0),
+			reset:  true,
+			line:   "0|1023\r\n",
+			want:   []float32{0, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			handleTestLine(sio, events, "512|1023\r\n", format)
+
+			if test.reset {
+				sio.Reset()
+			}
+
+			got := []float32{}
+			_, moved := handleTestLine(sio, events, test.line, format)
+			for _, event := range moved {
+				got = append(got, event.PercentValue)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got values %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNoDedup(t *testing.T) {
+	tests := []struct {
+		name    string
+		noDedup bool
+		want    []float32
+	}{
+		{name: "repeated readings are dropped", want: []float32{0.5}},
+		{name: "every reading is an event", noDedup: true, want: []float32{0.5, 0.5, 0.5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{NoDedup: test.noDedup})
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			got := []float32{}
+			for _, line := range []string{"512\r\n", "512\r\n", "513\r\n"} {
+				_, moved := handleTestLine(sio, events, line, format)
+				for _, event := range moved {
+					got = append(got, event.PercentValue)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got values %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeToMixerState(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     []string
+		after      []string
+		wantValues []float32
+		wantMoves  []float32
+	}{
+		{
+			name:       "nothing reported yet",
+			wantValues: []float32{},
+			wantMoves:  []float32{},
+		},
+		{
+			name:       "state, then moves",
+			before:     []string{"0|1023\r\n", "512|1023\r\n"},
+			after:      []string{"512|0\r\n"},
+			wantValues: []float32{0.5, 1},
+			wantMoves:  []float32{0},
+		},
+		{
+			name:       "slider count changed",
+			before:     []string{"512|1023\r\n", "256\r\n"},
+			after:      []string{"256\r\n", "0\r\n"},
+			wantValues: []float32{0.25},
+			wantMoves:  []float32{0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			format := newLineFormat("|", "\n", checksumNone)
+
+			// nobody's listening yet, so these don't block
+			for _, line := range test.before {
+				sio.handleLine(sio.logger, line, format)
+			}
+
+			values, events := sio.SubscribeToMixerState()
+
+			moves := []float32{}
+			for _, line := range test.after {
+				_, moved := handleTestLine(sio, events, line, format)
+				for _, event := range moved {
+					moves = append(moves, event.PercentValue)
+				}
+			}
+
+			if !reflect.DeepEqual(values, test.wantValues) {
+				t.Errorf("got state %v, want %v", values, test.wantValues)
+			}
+
+			if !reflect.DeepEqual(moves, test.wantMoves) {
+				t.Errorf("got moves %v, want %v", moves, test.wantMoves)
+			}
+		})
+	}
+}
+
+func TestSetSliderValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     CanonicalConfig
+		locked     bool
+		enabled    bool
+		value      float32
+		preview    bool
+		wantErr    error
+		wantEvents []SliderMoveEvent
+		wantStored float32
+	}{
+		{
+			name:       "regular slider",
+			value:      0.25,
+			enabled:    true,
+			wantEvents: []SliderMoveEvent{{SliderID: 0, PercentValue: 0.25}},
+			wantStored: 0.25,
+		},
+		{
+			name:       "bipolar slider",
+			config:     CanonicalConfig{BipolarSliders: []int{0}},
+			value:      -0.5,
+			enabled:    true,
+			wantEvents: []SliderMoveEvent{{SliderID: 0, PercentValue: -0.5, Bipolar: true}},
+			wantStored: 0.25,
+		},
+		{
+			name:    "regular slider below 0",
+			value:   -0.5,
+			enabled: true,
+			wantErr: ErrSliderValueOutOfRange,
+		},
+		{
+			name:    "bipolar slider below -1",
+			config:  CanonicalConfig{BipolarSliders: []int{0}},
+			value:   -1.5,
+			enabled: true,
+			wantErr: ErrSliderValueOutOfRange,
+		},
+		{
+			name:    "locked slider",
+			locked:  true,
+			value:   0.25,
+			enabled: true,
+			wantErr: ErrSliderLocked,
+		},
+		{
+			name:    "disabled slider",
+			config:  CanonicalConfig{DisabledSliders: []int{0}},
+			value:   0.25,
+			enabled: true,
+			wantErr: ErrSliderDisabled,
+		},
+		{
+			name:       "predicate says no",
+			value:      0.25,
+			wantStored: 0.25,
+		},
+		{
+			name:       "preview",
+			value:      0.25,
+			preview:    true,
+			enabled:    true,
+			wantEvents: []SliderMoveEvent{{SliderID: 0, PercentValue: 0.25, Preview: true}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			sio.valuesLock.Lock()
+			sio.resetSliders(1)
+			sio.valuesLock.Unlock()
+
+			sio.SetSliderLocked(0, test.locked)
+			sio.SetSliderEnabledFunc(0, func() bool { return test.enabled })
+
+			var err error
+			got := collectTestEvents(events, func() {
+				err = sio.SetSliderValue(0, test.value, test.preview)
+			})
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("SetSliderValue(%v) returned %v, want %v", test.value, err, test.wantErr)
+			}
+
+			want := test.wantEvents
+			if want == nil {
+				want = []SliderMoveEvent{}
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("SetSliderValue(%v) delivered %+v, want %+v", test.value, got, want)
+			}
+
+			// refused values aren't stored either
+			stored, ok := sio.CurrentValue(0)
+			if test.wantErr != nil && ok {
+				t.Errorf("refused value was stored as %v", stored)
+			} else if test.wantErr == nil && stored != test.wantStored {
+				t.Errorf("stored %v, want %v", stored, test.wantStored)
+			}
+		})
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		idleTimeout   time.Duration
+		wantConnected bool
+	}{
+		{"no idle timeout", 0, true},
+		{"idle for longer than the timeout", 50 * time.Millisecond, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{IdleTimeout: test.idleTimeout})
+
+			// the board sends a line to discard and a valid one, then goes quiet
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte("0\r\n512\r\n"))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.Start(); err != nil {
+				t.Fatalf("Start() returned an unexpected error: %v", err)
+			}
+
+			defer sio.Stop()
+
+			if err := sio.WaitForFirstLine(time.Second); err != nil {
+				t.Fatalf("WaitForFirstLine() returned an unexpected error: %v", err)
+			}
+
+			time.Sleep(4 * (test.idleTimeout + 50*time.Millisecond))
+
+			if connected := sio.Connected(); connected != test.wantConnected {
+				t.Errorf("connected = %v, want %v", connected, test.wantConnected)
+			}
+		})
+	}
+}
+
+func TestStartReportsImmediateReadFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		readErr error
+		wantErr error
+	}{
+		{name: "board sends lines", input: "0\r\n512\r\n"},
+		{name: "board is quiet"},
+		{
+			name:    "read fails right away",
+			readErr: errors.New("device reports readiness to read but returned no data"),
+			wantErr: errImmediateReadFailure,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go func() {
+				writer.Write([]byte(test.input))
+
+				if test.readErr != nil {
+					writer.CloseWithError(test.readErr)
+				}
+			}()
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			err := sio.Start()
+			defer sio.Stop()
+
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("Start() returned %v, want %v", err, test.wantErr)
+			}
+
+			// a failed connection isn't left lying around
+			if connected := sio.Connected(); connected != (test.wantErr == nil) {
+				t.Errorf("connected = %v, want %v", connected, test.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestStartupDelay(t *testing.T) {
+	const startupDelay = 200 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		startupDelay time.Duration
+		wantDuring   []float32
+		wantAfter    []float32
+	}{
+		{
+			name:       "no startup delay",
+			wantDuring: []float32{0.5},
+			wantAfter:  []float32{},
+		},
+		{
+			name:         "moves are ignored until the board settles",
+			startupDelay: startupDelay,
+			wantDuring:   []float32{},
+			wantAfter:    []float32{0.5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{StartupDelay: test.startupDelay})
+			events := sio.SubscribeToSliderMoveEvents()
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			// the board sends the same reading before and after settling
+			go writer.Write([]byte("0\r\n512\r\n"))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.Start(); err != nil {
+				t.Fatalf("Start() returned an unexpected error: %v", err)
+			}
+
+			defer sio.Stop()
+
+			collect := func() []float32 {
+				values := []float32{}
+				timeout := time.After(50 * time.Millisecond)
+
+				for {
+					select {
+					case event := <-events:
+						values = append(values, event.PercentValue)
+					case <-timeout:
+						return values
+					}
+				}
+			}
+
+			if got := collect(); !reflect.DeepEqual(got, test.wantDuring) {
+				t.Errorf("got values %v during the startup delay, want %v", got, test.wantDuring)
+			}
+
+			time.Sleep(startupDelay)
+			go writer.Write([]byte("512\r\n"))
+
+			if got := collect(); !reflect.DeepEqual(got, test.wantAfter) {
+				t.Errorf("got values %v after the startup delay, want %v", got, test.wantAfter)
+			}
+		})
+	}
+}
+
+func TestWaitForFirstLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		connect bool
+		input   string
+		wantErr bool
+	}{
+		{name: "not connected", wantErr: true},
+		{name: "first line arrives", connect: true, input: "0\r\n512\r\n"},
+		{name: "only garbage arrives", connect: true, input: "hello there\r\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte(test.input))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if test.connect {
+				if err := sio.Start(); err != nil {
+					t.Fatalf("Start() returned an unexpected error: %v", err)
+				}
+
+				defer sio.Stop()
+			}
+
+			if err := sio.WaitForFirstLine(100 * time.Millisecond); (err != nil) != test.wantErr {
+				t.Errorf("WaitForFirstLine() returned %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestTelemetryLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		terminator    string
+		input         string
+		wantTelemetry []string
+		wantValues    []float32
+	}{
+		{
+			name:          "debug print between values",
+			terminator:    ";",
+			input:         "0;boot ok\n512;1023;",
+			wantTelemetry: []string{"boot ok\n"},
+			wantValues:    []float32{0.5, 1},
+		},
+		{
+			name:          "debug print split across value lines",
+			terminator:    ";",
+			input:         "0;512;free mem: 1023\n1023;",
+			wantTelemetry: []string{"free mem: 1023\n"},
+			wantValues:    []float32{0.5, 1},
+		},
+		{
+			name:          "default terminator has no telemetry",
+			terminator:    defaultLineTerminator,
+			input:         "0\r\nboot ok\r\n1023\r\n",
+			wantTelemetry: []string{},
+			wantValues:    []float32{1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := CanonicalConfig{FieldSeparator: defaultFieldSeparator, LineTerminator: test.terminator}
+			config.Checksum = checksumNone
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			telemetry := sio.SubscribeToTelemetryLines()
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte(test.input))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.Start(); err != nil {
+				t.Fatalf("Start() returned an unexpected error: %v", err)
+			}
+
+			defer sio.Stop()
+
+			// telemetry always comes before the last value in these inputs
+			gotTelemetry := []string{}
+			gotValues := []float32{}
+			for len(gotValues) < len(test.wantValues) {
+				select {
+				case line := <-telemetry:
+					gotTelemetry = append(gotTelemetry, line)
+				case event := <-events:
+					gotValues = append(gotValues, event.PercentValue)
+				case <-time.After(time.Second):
+					t.Fatalf("got values %v and telemetry %q before timing out", gotValues, gotTelemetry)
+				}
+			}
+
+			if !reflect.DeepEqual(gotTelemetry, test.wantTelemetry) {
+				t.Errorf("got telemetry %q, want %q", gotTelemetry, test.wantTelemetry)
+			}
+
+			if !reflect.DeepEqual(gotValues, test.wantValues) {
+				t.Errorf("got values %v, want %v", gotValues, test.wantValues)
+			}
+		})
+	}
+}
+
+func TestReadersStopWithTheReadLoop(t *testing.T) {
+	tests := []struct {
+		name  string
+		start func(sio *SerialIO, reader *bufio.Reader, errChannel chan error, doneChannel chan bool)
+	}{
+		{
+			name: "lines",
+			start: func(sio *SerialIO, reader *bufio.Reader, errChannel chan error, doneChannel chan bool) {
+				sio.readLine(sio.logger, reader, errChannel, doneChannel)
+			},
+		},
+		{
+			name: "frames",
+			start: func(sio *SerialIO, reader *bufio.Reader, errChannel chan error, doneChannel chan bool) {
+				sio.readFrames(sio.logger, reader, errChannel, doneChannel)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			input := "512\r\n" + string(encodeTestFrame(0, 512, 0, binary.BigEndian)) + "512\r\n"
+			sio.lineFormat.Store(newLineFormat("|", "\n", checksumNone))
+
+			before := runtime.NumGoroutine()
+
+			// the read loop has stopped (i.e. on idle timeout) and nobody takes what's been read anymore
+			doneChannel := make(chan bool)
+			test.start(sio, bufio.NewReader(strings.NewReader(input)), make(chan error, 1), doneChannel)
+			close(doneChannel)
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > before {
+				if time.Now().After(deadline) {
+					t.Fatal("reader goroutine is still blocked after the read loop stopped")
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestConnectionParamsChanged(t *testing.T) {
+	tests := []struct {
+		name           string
+		baudRate       int
+		readBufferSize int
+		want           bool
+	}{
+		{"unchanged", 9600, defaultReadBufferSize, false},
+		{"baud rate", 115200, defaultReadBufferSize, true},
+		{"read buffer size", 9600, 65536, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{}
+			config.ConnectionInfo.COMPort = "COM4"
+			config.ConnectionInfo.BaudRate = test.baudRate
+			config.ConnectionInfo.ReadBufferSize = test.readBufferSize
+
+			sio := newTestSerialIO(t, config)
+
+			// as if connected with the defaults
+			sio.connOptions.PortName = "COM4"
+			sio.connOptions.BaudRate = 9600
+			sio.readBufferSize = defaultReadBufferSize
+
+			if got := sio.connectionParamsChanged(); got != test.want {
+				t.Errorf("connectionParamsChanged() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConfigReloadsCoalesceRenewals(t *testing.T) {
+	tests := []struct {
+		name      string
+		comPort   string
+		wantOpens int32
+	}{
+		{"unchanged connection params", "", 0},
+		{"changed port", "COM5", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{}
+			config.ConnectionInfo.COMPort = test.comPort
+
+			sio := newTestSerialIO(t, config)
+
+			// hold the renewal up at the port until we've sent every reload
+			opened := make(chan bool)
+			release := make(chan bool)
+
+			var opens int32
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				atomic.AddInt32(&opens, 1)
+				opened <- true
+				<-release
+
+				return nil, errors.New("no board attached")
+			}
+
+			// reloads are delivered on an unbuffered channel, so each one has been taken by the time it returns
+			reloadsDone := make(chan bool)
+			go func() {
+				for i := 0; i < 5; i++ {
+					config.onConfigReloaded()
+
+					if i == 0 && test.wantOpens > 0 {
+						<-opened
+					}
+				}
+
+				close(reloadsDone)
+			}()
+
+			select {
+			case <-reloadsDone:
+			case <-time.After(time.Second):
+				t.Fatal("config reloads blocked on the connection renewal")
+			}
+
+			close(release)
+
+			// the pending renewal finds the connection params up to date, so it doesn't open the port again
+			time.Sleep(50 * time.Millisecond)
+
+			if got := atomic.LoadInt32(&opens); got != test.wantOpens {
+				t.Errorf("opened the port %d times, want %d", got, test.wantOpens)
+			}
+		})
+	}
+}
+
+// readCountingReader counts how many reads it took to get through its input, standing in for syscalls
+type readCountingReader struct {
+	reader io.Reader
+	reads  int
+}
+
+func (r *readCountingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.reader.Read(p)
+}
+
+func BenchmarkReadBoundedLine(b *testing.B) {
+
+	// a chatty board: lots of sliders, sent as fast as it can
+	lines := strings.Repeat("1023|512|0|300|700|1023|512|0\r\n", 10000)
+
+	for _, bufferSize := range []int{defaultReadBufferSize, 16 * 1024, 64 * 1024} {
+		b.Run(fmt.Sprintf("buffer %d", bufferSize), func(b *testing.B) {
+			sio := newTestSerialIO(b, &CanonicalConfig{})
+			format := newLineFormat("|", "\n", checksumNone)
+
+			b.SetBytes(int64(len(lines)))
+			b.ResetTimer()
+
+			reads := 0
+			for idx := 0; idx < b.N; idx++ {
+				counter := &readCountingReader{reader: strings.NewReader(lines)}
+				reader := bufio.NewReaderSize(counter, bufferSize)
+
+				for {
+					if _, err := sio.readBoundedLine(sio.logger, reader, format); err != nil {
+						break
+					}
+				}
+
+				reads += counter.reads
+			}
+
+			b.ReportMetric(float64(reads)/float64(b.N), "reads/op")
+		})
+	}
+}
+
+func TestLineFormatChangesOnConfigReload(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		want      []float32
+	}{
+		{name: "unchanged format", separator: "|", want: []float32{0.25, 0.5, 0.5}},
+		{name: "changed separator", separator: ",", want: []float32{0.25, 0, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{}
+			sio := newTestSerialIO(t, config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			reader, writer := io.Pipe()
+			defer writer.Close()
+
+			go writer.Write([]byte("0\r\n512|1023\r\n"))
+
+			sio.openPort = func(serial.OpenOptions) (io.ReadWriteCloser, error) {
+				return &testConn{Reader: reader}, nil
+			}
+
+			if err := sio.Start(); err != nil {
+				t.Fatalf("Start() returned an unexpected error: %v", err)
+			}
+
+			defer sio.Stop()
+
+			for i := 0; i < 2; i++ {
+				select {
+				case <-events:
+				case <-time.After(time.Second):
+					t.Fatal("timed out waiting for the first line")
+				}
+			}
+
+			config.FieldSeparator = test.separator
+			config.onConfigReloaded()
+			time.Sleep(50 * time.Millisecond)
+
+			// a line that was already being read finishes with the old format, so start with one that's valid in both
+			go writer.Write([]byte("256\r\n0,1023\r\n512|512\r\n"))
+
+			got := []float32{}
+			timeout := time.After(100 * time.Millisecond)
+
+			for collecting := true; collecting; {
+				select {
+				case event := <-events:
+					got = append(got, event.PercentValue)
+				case <-timeout:
+					collecting = false
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got values %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -208,6 +208,16 @@ func (m *sessionMap) sessionMapped(session Session) bool {
 
 func (m *sessionMap) handleSliderMoveEvent(event SliderMoveEvent) {
 
+	// previews are for display purposes only, they'll be followed up by a regular event once committed
+	if event.Preview {
+		return
+	}
+
+	// bipolar sliders (i.e. pan controls) don't represent a volume
+	if event.Bipolar {
+		return
+	}
+
 	// first of all, ensure our session map isn't moldy
 	if m.lastSessionRefresh.Add(maxTimeBetweenSessionRefreshes).Before(time.Now()) {
 		m.logger.Debug("Stale session map detected on slider move, refreshing")
@@ -224,6 +234,7 @@ func (m *sessionMap) handleSliderMoveEvent(event SliderMoveEvent) {
 
 	targetFound := false
 	adjustmentFailed := false
+	var adjustmentErr error
 
 	// for each possible target for this slider...
 	for _, target := range targets {
@@ -251,6 +262,7 @@ func (m *sessionMap) handleSliderMoveEvent(event SliderMoveEvent) {
 					if err := session.SetVolume(event.PercentValue); err != nil {
 						m.logger.Warnw("Failed to set target session volume", "error", err)
 						adjustmentFailed = true
+						adjustmentErr = err
 					}
 				}
 			}
@@ -261,6 +273,7 @@ func (m *sessionMap) handleSliderMoveEvent(event SliderMoveEvent) {
 	// processes could've opened since the last time this slider moved.
 	// if they haven't, the cooldown will take care to not spam it up
 	if !targetFound {
+		adjustmentErr = errNoTargetSession
 		m.refreshSessions(false)
 	} else if adjustmentFailed {
 
@@ -269,6 +282,13 @@ func (m *sessionMap) handleSliderMoveEvent(event SliderMoveEvent) {
 		// (or another, more catastrophic failure happens)
 		m.refreshSessions(true)
 	}
+
+	// let the serial side know how it went, so it can give the board some feedback
+	m.deej.serial.ReportApplyResult(ApplyResult{
+		SliderID:     event.SliderID,
+		PercentValue: event.PercentValue,
+		Err:          adjustmentErr,
+	})
 }
 
 func (m *sessionMap) targetHasSpecialTransform(target string) bool {
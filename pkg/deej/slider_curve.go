@@ -0,0 +1,83 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// curveBreakpoint maps a single slider position (input) to a volume (output), both between 0.0 and 1.0
+type curveBreakpoint struct {
+	Input  float32
+	Output float32
+}
+
+// sliderCurve is a piecewise-linear mapping from slider position to volume, interpolated between its breakpoints.
+// it always starts at an input of 0.0 and ends at an input of 1.0, with strictly increasing inputs in between
+type sliderCurve []curveBreakpoint
+
+// breakpoints are written as "input:output" in the config, i.e. "0.5:0.2"
+const curveBreakpointSeparator = ":"
+
+func parseSliderCurve(breakpointStrings []string) (sliderCurve, error) {
+	curve := make(sliderCurve, 0, len(breakpointStrings))
+
+	for _, breakpointString := range breakpointStrings {
+		parts := strings.Split(breakpointString, curveBreakpointSeparator)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("breakpoint %q isn't formatted as input%soutput", breakpointString, curveBreakpointSeparator)
+		}
+
+		input, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse breakpoint %q input: %w", breakpointString, err)
+		}
+
+		output, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse breakpoint %q output: %w", breakpointString, err)
+		}
+
+		if output < 0 || output > 1 {
+			return nil, fmt.Errorf("breakpoint %q output must be between 0.0 and 1.0", breakpointString)
+		}
+
+		curve = append(curve, curveBreakpoint{Input: float32(input), Output: float32(output)})
+	}
+
+	if len(curve) < 2 {
+		return nil, errors.New("curve must have at least 2 breakpoints")
+	}
+
+	if curve[0].Input != 0 || curve[len(curve)-1].Input != 1 {
+		return nil, errors.New("curve must start at an input of 0.0 and end at an input of 1.0")
+	}
+
+	for idx := 1; idx < len(curve); idx++ {
+		if curve[idx].Input <= curve[idx-1].Input {
+			return nil, fmt.Errorf("breakpoint inputs must be strictly increasing (%v after %v)",
+				curve[idx].Input, curve[idx-1].Input)
+		}
+	}
+
+	return curve, nil
+}
+
+// apply maps the given slider position through the curve
+func (sc sliderCurve) apply(value float32) float32 {
+	if value <= sc[0].Input {
+		return sc[0].Output
+	}
+
+	for idx := 1; idx < len(sc); idx++ {
+		lower, upper := sc[idx-1], sc[idx]
+
+		if value <= upper.Input {
+			position := (value - lower.Input) / (upper.Input - lower.Input)
+			return lower.Output + position*(upper.Output-lower.Output)
+		}
+	}
+
+	return sc[len(sc)-1].Output
+}
@@ -0,0 +1,79 @@
+package deej
+
+import (
+	"sync"
+)
+
+// sliderEventQueue delivers move events to a single consumer on its own goroutine, so a slow consumer only
+// holds itself up rather than every other consumer and the serial read loop. unlike a sliderMailbox, every
+// event is kept - up to a limit, past which the oldest ones are dropped to make room
+type sliderEventQueue struct {
+	consumer chan SliderMoveEvent
+
+	pending []SliderMoveEvent
+	lock    sync.Locker
+
+	notify chan bool
+}
+
+func newSliderEventQueue(consumer chan SliderMoveEvent) *sliderEventQueue {
+	eq := &sliderEventQueue{
+		consumer: consumer,
+		lock:     &sync.Mutex{},
+		notify:   make(chan bool, 1),
+	}
+
+	go eq.deliver()
+
+	return eq
+}
+
+// put queues up the given events, and returns how many older events had to be dropped to make room for them
+func (eq *sliderEventQueue) put(moveEvents []SliderMoveEvent, limit int) int {
+	eq.lock.Lock()
+
+	eq.pending = append(eq.pending, moveEvents...)
+
+	dropped := 0
+	if overflow := len(eq.pending) - limit; overflow > 0 {
+		eq.pending = eq.pending[overflow:]
+		dropped = overflow
+	}
+
+	eq.lock.Unlock()
+
+	// wake the delivery goroutine up, unless it's already been woken
+	select {
+	case eq.notify <- true:
+	default:
+	}
+
+	return dropped
+}
+
+func (eq *sliderEventQueue) take() (SliderMoveEvent, bool) {
+	eq.lock.Lock()
+	defer eq.lock.Unlock()
+
+	if len(eq.pending) == 0 {
+		return SliderMoveEvent{}, false
+	}
+
+	event := eq.pending[0]
+	eq.pending = eq.pending[1:]
+
+	return event, true
+}
+
+func (eq *sliderEventQueue) deliver() {
+	for range eq.notify {
+		for {
+			event, ok := eq.take()
+			if !ok {
+				break
+			}
+
+			eq.consumer <- event
+		}
+	}
+}
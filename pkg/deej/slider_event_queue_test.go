@@ -0,0 +1,118 @@
+package deej
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSliderEventQueue(t *testing.T) {
+	event := func(value float32) SliderMoveEvent {
+		return SliderMoveEvent{SliderID: 0, PercentValue: value}
+	}
+
+	tests := []struct {
+		name        string
+		limit       int
+		put         [][]SliderMoveEvent
+		want        []SliderMoveEvent
+		wantDropped int
+	}{
+		{
+			name:  "every event, in order",
+			limit: 10,
+			put:   [][]SliderMoveEvent{{event(0.1), event(0.2)}, {event(0.3)}},
+			want:  []SliderMoveEvent{event(0.1), event(0.2), event(0.3)},
+		},
+		{
+			name:        "oldest events are dropped past the limit",
+			limit:       2,
+			put:         [][]SliderMoveEvent{{event(0.1), event(0.2)}, {event(0.3)}},
+			want:        []SliderMoveEvent{event(0.2), event(0.3)},
+			wantDropped: 1,
+		},
+		{
+			name:        "a single put past the limit",
+			limit:       2,
+			put:         [][]SliderMoveEvent{{event(0.1), event(0.2), event(0.3), event(0.4)}},
+			want:        []SliderMoveEvent{event(0.3), event(0.4)},
+			wantDropped: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// no delivery goroutine, so that nothing gets taken out from under us
+			eq := &sliderEventQueue{
+				lock:   &sync.Mutex{},
+				notify: make(chan bool, 1),
+			}
+
+			dropped := 0
+			for _, moveEvents := range test.put {
+				dropped += eq.put(moveEvents, test.limit)
+			}
+
+			got := []SliderMoveEvent{}
+			for {
+				event, ok := eq.take()
+				if !ok {
+					break
+				}
+
+				got = append(got, event)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("took %+v, want %+v", got, test.want)
+			}
+
+			if dropped != test.wantDropped {
+				t.Errorf("dropped %d events, want %d", dropped, test.wantDropped)
+			}
+		})
+	}
+}
+
+func TestSliderEventQueueIsolatesSlowConsumers(t *testing.T) {
+	sio := newTestSerialIO(t, &CanonicalConfig{SliderEventQueueSize: 10})
+	format := newLineFormat("|", "\n", checksumNone)
+
+	// this one never reads, and shouldn't hold up the other one or the read loop
+	sio.SubscribeToSliderMoveEvents()
+	events := sio.SubscribeToSliderMoveEvents()
+
+	lines := []string{"0\r\n", "512\r\n", "1023\r\n"}
+	want := []float32{0, 0.5, 1}
+
+	handled := make(chan bool)
+	go func() {
+		for _, line := range lines {
+			sio.handleLine(sio.logger, line, format)
+		}
+
+		close(handled)
+	}()
+
+	got := []float32{}
+	for len(got) < len(want) {
+		select {
+		case event := <-events:
+			got = append(got, event.PercentValue)
+		case <-time.After(time.Second):
+			t.Fatalf("got values %v before timing out, want %v", got, want)
+		}
+	}
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("handling lines blocked on the slow consumer")
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package deej
+
+import (
+	"sync"
+)
+
+// sliderMailbox holds the latest undelivered move event of each slider for a single consumer.
+// newer events overwrite older ones for the same slider, so a consumer that falls behind
+// skips straight to the most recent value instead of working through a stale backlog.
+// previews get slots of their own, so that they never overwrite a value that's yet to be committed
+type sliderMailbox struct {
+	consumer chan SliderMoveEvent
+
+	pending map[sliderMailboxSlot]SliderMoveEvent
+	order   []sliderMailboxSlot
+	lock    sync.Locker
+
+	notify chan bool
+}
+
+type sliderMailboxSlot struct {
+	sliderID int
+	preview  bool
+}
+
+func newSliderMailbox(consumer chan SliderMoveEvent) *sliderMailbox {
+	mb := &sliderMailbox{
+		consumer: consumer,
+		pending:  make(map[sliderMailboxSlot]SliderMoveEvent),
+		lock:     &sync.Mutex{},
+		notify:   make(chan bool, 1),
+	}
+
+	go mb.deliver()
+
+	return mb
+}
+
+func (mb *sliderMailbox) put(event SliderMoveEvent) {
+	mb.lock.Lock()
+
+	slot := sliderMailboxSlot{sliderID: event.SliderID, preview: event.Preview}
+
+	// a committed value makes any pending preview of the same slider stale
+	if !event.Preview {
+		mb.remove(sliderMailboxSlot{sliderID: event.SliderID, preview: true})
+	}
+
+	// keep delivering sliders in the order they first moved
+	if _, ok := mb.pending[slot]; !ok {
+		mb.order = append(mb.order, slot)
+	}
+
+	mb.pending[slot] = event
+	mb.lock.Unlock()
+
+	// wake the delivery goroutine up, unless it's already been woken
+	select {
+	case mb.notify <- true:
+	default:
+	}
+}
+
+func (mb *sliderMailbox) take() (SliderMoveEvent, bool) {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	if len(mb.order) == 0 {
+		return SliderMoveEvent{}, false
+	}
+
+	slot := mb.order[0]
+	mb.order = mb.order[1:]
+
+	event := mb.pending[slot]
+	delete(mb.pending, slot)
+
+	return event, true
+}
+
+// remove drops the given slot's pending event, if there is one. assumes the lock is held
+func (mb *sliderMailbox) remove(slot sliderMailboxSlot) {
+	if _, ok := mb.pending[slot]; !ok {
+		return
+	}
+
+	delete(mb.pending, slot)
+
+	for idx, orderedSlot := range mb.order {
+		if orderedSlot == slot {
+			mb.order = append(mb.order[:idx], mb.order[idx+1:]...)
+			break
+		}
+	}
+}
+
+func (mb *sliderMailbox) deliver() {
+	for range mb.notify {
+
+		// take events one at a time, so slots keep getting overwritten while we wait on the consumer
+		for {
+			event, ok := mb.take()
+			if !ok {
+				break
+			}
+
+			mb.consumer <- event
+		}
+	}
+}
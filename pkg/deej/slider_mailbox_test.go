@@ -0,0 +1,114 @@
+package deej
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSliderMailbox(t *testing.T) {
+	committed := func(sliderID int, value float32) SliderMoveEvent {
+		return SliderMoveEvent{SliderID: sliderID, PercentValue: value}
+	}
+
+	preview := func(sliderID int, value float32) SliderMoveEvent {
+		return SliderMoveEvent{SliderID: sliderID, PercentValue: value, Preview: true}
+	}
+
+	tests := []struct {
+		name string
+		put  []SliderMoveEvent
+		want []SliderMoveEvent
+	}{
+		{
+			name: "latest value per slider, in the order they first moved",
+			put:  []SliderMoveEvent{committed(0, 0.1), committed(1, 0.2), committed(0, 0.3)},
+			want: []SliderMoveEvent{committed(0, 0.3), committed(1, 0.2)},
+		},
+		{
+			name: "preview doesn't replace an undelivered committed value",
+			put:  []SliderMoveEvent{committed(0, 0.5), preview(0, 0.6)},
+			want: []SliderMoveEvent{committed(0, 0.5), preview(0, 0.6)},
+		},
+		{
+			name: "previews replace each other",
+			put:  []SliderMoveEvent{preview(0, 0.1), preview(0, 0.2)},
+			want: []SliderMoveEvent{preview(0, 0.2)},
+		},
+		{
+			name: "committed value drops a pending preview",
+			put:  []SliderMoveEvent{preview(0, 0.6), committed(0, 0.7)},
+			want: []SliderMoveEvent{committed(0, 0.7)},
+		},
+		{
+			name: "committed value after a committed value and a preview",
+			put:  []SliderMoveEvent{committed(0, 0.5), preview(0, 0.6), committed(0, 0.7)},
+			want: []SliderMoveEvent{committed(0, 0.7)},
+		},
+		{
+			name: "previews of other sliders are kept",
+			put:  []SliderMoveEvent{preview(1, 0.4), committed(0, 0.5)},
+			want: []SliderMoveEvent{preview(1, 0.4), committed(0, 0.5)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			// no delivery goroutine, so that nothing gets taken out from under us
+			mb := &sliderMailbox{
+				pending: make(map[sliderMailboxSlot]SliderMoveEvent),
+				lock:    &sync.Mutex{},
+				notify:  make(chan bool, 1),
+			}
+
+			for _, event := range test.put {
+				mb.put(event)
+			}
+
+			got := []SliderMoveEvent{}
+			for {
+				event, ok := mb.take()
+				if !ok {
+					break
+				}
+
+				got = append(got, event)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("took %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSliderMailboxDeliversLatestValue(t *testing.T) {
+	consumer := make(chan SliderMoveEvent)
+	mb := newSliderMailbox(consumer)
+
+	// a burst of moves while the consumer isn't reading
+	const burstSize = 100
+	for idx := 1; idx <= burstSize; idx++ {
+		mb.put(SliderMoveEvent{SliderID: 0, PercentValue: float32(idx) / burstSize})
+	}
+
+	received := 0
+	for {
+		select {
+		case event := <-consumer:
+			received++
+
+			if event.PercentValue == 1 {
+				if received > burstSize {
+					t.Errorf("received %d events for a burst of %d", received, burstSize)
+				}
+
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("latest value never delivered (received %d events)", received)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package deej
+
+import (
+	"sync"
+	"time"
+)
+
+// SliderPeakEvent carries the highest value a slider has recently reached, for VU meter style displays
+type SliderPeakEvent struct {
+	SliderID  int
+	PeakValue float32
+}
+
+// sliderPeak is a single slider's peak, and when it was reached
+type sliderPeak struct {
+	value float32
+	at    time.Time
+}
+
+// how often peaks decay (and are re-emitted as they do)
+const peakUpdateInterval = 50 * time.Millisecond
+
+// peakTracker follows slider moves for a single peak subscriber. moves are observed from the read loop, which
+// must never wait on the subscriber - so changed peaks are coalesced per slider until it's ready for them
+type peakTracker struct {
+	consumer chan SliderPeakEvent
+
+	peaks   map[int]*sliderPeak
+	current map[int]float32
+	pending map[int]float32
+	order   []int
+	lock    sync.Locker
+
+	notify chan bool
+
+	// closed to stop the tracker's goroutine, nil while it isn't running. guarded by the serial consumers lock
+	stopChannel chan bool
+}
+
+// SubscribeToPeaks returns an unbuffered channel that receives each slider's peak value whenever it changes.
+// A peak is held for the configured hold time after being reached, and then decays at the configured rate
+// until it meets the slider's current value again. Peaks only decay while connected, and a subscriber
+// that falls behind only gets the latest peak of each slider
+func (sio *SerialIO) SubscribeToPeaks() chan SliderPeakEvent {
+	pt := &peakTracker{
+		consumer: make(chan SliderPeakEvent),
+		peaks:    make(map[int]*sliderPeak),
+		current:  make(map[int]float32),
+		pending:  make(map[int]float32),
+		lock:     &sync.Mutex{},
+		notify:   make(chan bool, 1),
+	}
+
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.peakTrackers = append(sio.peakTrackers, pt)
+
+	// otherwise it's started along with the next connection
+	if sio.peakTrackersRunning {
+		pt.stopChannel = make(chan bool)
+		go pt.run(sio, pt.stopChannel)
+	}
+
+	return pt.consumer
+}
+
+// startPeakTrackers starts every peak tracker's goroutine, for as long as we're connected
+func (sio *SerialIO) startPeakTrackers() {
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.peakTrackersRunning = true
+
+	for _, pt := range sio.peakTrackers {
+		if pt.stopChannel == nil {
+			pt.stopChannel = make(chan bool)
+			go pt.run(sio, pt.stopChannel)
+		}
+	}
+}
+
+// stopPeakTrackers stops every peak tracker's goroutine (and its ticker). their subscriptions stay intact
+func (sio *SerialIO) stopPeakTrackers() {
+	sio.consumersLock.Lock()
+	defer sio.consumersLock.Unlock()
+
+	sio.peakTrackersRunning = false
+
+	for _, pt := range sio.peakTrackers {
+		if pt.stopChannel != nil {
+			close(pt.stopChannel)
+			pt.stopChannel = nil
+		}
+	}
+}
+
+// observePeaks lets every peak tracker know about the given move events, without waiting on any subscriber
+func (sio *SerialIO) observePeaks(moveEvents []SliderMoveEvent) {
+	sio.consumersLock.Lock()
+	trackers := sio.peakTrackers
+	sio.consumersLock.Unlock()
+
+	for _, pt := range trackers {
+		pt.observe(moveEvents)
+	}
+}
+
+func (pt *peakTracker) observe(moveEvents []SliderMoveEvent) {
+	pt.lock.Lock()
+
+	for _, event := range moveEvents {
+
+		// previews never actually happened, and bipolar values aren't levels
+		if event.Preview || event.Bipolar {
+			continue
+		}
+
+		pt.current[event.SliderID] = event.PercentValue
+
+		peak, ok := pt.peaks[event.SliderID]
+		if ok && event.PercentValue < peak.value {
+			continue
+		}
+
+		pt.peaks[event.SliderID] = &sliderPeak{value: event.PercentValue, at: time.Now()}
+		pt.setPending(event.SliderID, event.PercentValue)
+	}
+
+	pt.lock.Unlock()
+	pt.wake()
+}
+
+// decay lowers every peak that's been held for long enough by the given amount, down to its slider's current value
+func (pt *peakTracker) decay(now time.Time, hold time.Duration, amount float32) {
+	pt.lock.Lock()
+
+	for sliderID, peak := range pt.peaks {
+		if peak.value <= pt.current[sliderID] || now.Sub(peak.at) < hold {
+			continue
+		}
+
+		peak.value -= amount
+		if peak.value < pt.current[sliderID] {
+			peak.value = pt.current[sliderID]
+		}
+
+		pt.setPending(sliderID, peak.value)
+	}
+
+	pt.lock.Unlock()
+	pt.wake()
+}
+
+// setPending replaces the given slider's undelivered peak, if there is one. assumes the lock is held
+func (pt *peakTracker) setPending(sliderID int, value float32) {
+	if _, ok := pt.pending[sliderID]; !ok {
+		pt.order = append(pt.order, sliderID)
+	}
+
+	pt.pending[sliderID] = value
+}
+
+func (pt *peakTracker) take() (SliderPeakEvent, bool) {
+	pt.lock.Lock()
+	defer pt.lock.Unlock()
+
+	if len(pt.order) == 0 {
+		return SliderPeakEvent{}, false
+	}
+
+	sliderID := pt.order[0]
+	pt.order = pt.order[1:]
+
+	value := pt.pending[sliderID]
+	delete(pt.pending, sliderID)
+
+	return SliderPeakEvent{SliderID: sliderID, PeakValue: value}, true
+}
+
+// wake wakes the tracker's goroutine up, unless it's already been woken
+func (pt *peakTracker) wake() {
+	select {
+	case pt.notify <- true:
+	default:
+	}
+}
+
+func (pt *peakTracker) run(sio *SerialIO, stopChannel chan bool) {
+	ticker := time.NewTicker(peakUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChannel:
+			return
+		case now := <-ticker.C:
+			pt.decay(now, sio.deej.config.Peaks.Hold,
+				sio.deej.config.Peaks.DecayRate*float32(peakUpdateInterval.Seconds()))
+		case <-pt.notify:
+		}
+
+		// take peaks one at a time, so they keep getting replaced while we wait on the subscriber
+		for {
+			event, ok := pt.take()
+			if !ok {
+				break
+			}
+
+			select {
+			case pt.consumer <- event:
+			case <-stopChannel:
+
+				// hold on to it for when we're started again, unless it's been replaced already
+				pt.lock.Lock()
+				if _, ok := pt.pending[event.SliderID]; !ok {
+					pt.setPending(event.SliderID, event.PeakValue)
+				}
+				pt.lock.Unlock()
+
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPeaksHoldThenDecay(t *testing.T) {
+	tests := []struct {
+		name      string
+		hold      time.Duration
+		decayRate float32
+		values    []float32
+		want      []float32
+	}{
+		{
+			name:      "decays to the current value after the hold",
+			hold:      200 * time.Millisecond,
+			decayRate: 8,
+			values:    []float32{1, 0.2},
+			want:      []float32{1, 0.6, 0.2},
+		},
+		{
+			name:      "decays all the way without a hold",
+			decayRate: 8,
+			values:    []float32{0.9, 0},
+			want:      []float32{0.9, 0.5, 0.1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &CanonicalConfig{}
+			config.Peaks.Hold = test.hold
+			config.Peaks.DecayRate = test.decayRate
+
+			sio := newTestSerialIO(t, config)
+			sio.valuesLock.Lock()
+			sio.resetSliders(1)
+			sio.valuesLock.Unlock()
+
+			peaks := sio.SubscribeToPeaks()
+			sio.startPeakTrackers()
+			defer sio.stopPeakTrackers()
+
+			setAt := time.Now()
+			for _, value := range test.values {
+				if err := sio.SetSliderValue(0, value, false); err != nil {
+					t.Fatalf("set slider value: %v", err)
+				}
+			}
+
+			got := []float32{}
+			for len(got) < len(test.want) {
+				select {
+				case event := <-peaks:
+
+					// the peak itself arrives right away, anything after it only once it's been held
+					if len(got) == 1 && time.Since(setAt) < test.hold {
+						t.Errorf("peak started decaying after %s, before its %s hold", time.Since(setAt), test.hold)
+					}
+
+					got = append(got, float32(int(event.PeakValue*10+0.5))/10)
+				case <-time.After(time.Second):
+					t.Fatalf("got peaks %v, want %v", got, test.want)
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got peaks %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPeaksDontHoldUpDelivery(t *testing.T) {
+	config := &CanonicalConfig{}
+	config.Peaks.DecayRate = 1
+
+	sio := newTestSerialIO(t, config)
+	sio.valuesLock.Lock()
+	sio.resetSliders(1)
+	sio.valuesLock.Unlock()
+
+	// nobody ever reads these
+	peaks := sio.SubscribeToPeaks()
+	sio.startPeakTrackers()
+
+	done := make(chan bool)
+	go func() {
+		for idx := 0; idx <= 100; idx++ {
+			sio.SetSliderValue(0, float32(idx)/100, false)
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("slider moves are blocked on a stalled peak subscriber")
+	}
+
+	// besides the one that was already on its way, only the latest peak is waiting for the subscriber
+	for received := 0; ; received++ {
+		event := <-peaks
+		if event.PeakValue == 1 {
+			break
+		}
+
+		if received > 0 {
+			t.Fatalf("stalled subscriber got peak %v, want the latest one", event.PeakValue)
+		}
+	}
+
+	// nothing is sent once stopped, after giving the tracker's goroutine a moment to notice
+	sio.stopPeakTrackers()
+	time.Sleep(peakUpdateInterval)
+
+	sio.SetSliderValue(0, 0.5, false)
+	sio.SetSliderValue(0, 1, false)
+
+	select {
+	case event := <-peaks:
+		t.Errorf("got peak %+v after stopping", event)
+	case <-time.After(3 * peakUpdateInterval):
+	}
+}
@@ -0,0 +1,87 @@
+package deej
+
+import (
+	"sync"
+	"time"
+)
+
+// SliderRailedEvent represents a slider that's been stuck at one of its extremes for suspiciously long,
+// which usually means its pot (or the wiring to it) has failed
+type SliderRailedEvent struct {
+	SliderID int
+	RawValue int
+	Duration time.Duration
+}
+
+type railedSlider struct {
+	rawValue int
+	since    time.Time
+	reported bool
+}
+
+// railTracker keeps track of how long each slider's been reporting one of its extremes without a single
+// value in between, and reports each such stretch once it goes on for longer than the configured delay
+type railTracker struct {
+	railed  map[int]*railedSlider
+	pending []SliderRailedEvent
+	lock    sync.Locker
+}
+
+func newRailTracker() *railTracker {
+	return &railTracker{
+		railed: make(map[int]*railedSlider),
+		lock:   &sync.Mutex{},
+	}
+}
+
+// reset forgets about all railed sliders, so they start over the next time they report
+func (rt *railTracker) reset() {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.railed = make(map[int]*railedSlider)
+	rt.pending = nil
+}
+
+// observe takes note of a slider's raw value. once it's been railed for longer than the delay,
+// an event is queued up for the next call to take
+func (rt *railTracker) observe(sliderIdx int, rawValue int, maxRawValue int, delay time.Duration) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	if rawValue > 0 && rawValue < maxRawValue {
+		delete(rt.railed, sliderIdx)
+		return
+	}
+
+	now := time.Now()
+
+	// a slider that jumps straight from one extreme to the other starts over
+	railed, ok := rt.railed[sliderIdx]
+	if !ok || railed.rawValue != rawValue {
+		rt.railed[sliderIdx] = &railedSlider{rawValue: rawValue, since: now}
+		return
+	}
+
+	if railed.reported || now.Sub(railed.since) < delay {
+		return
+	}
+
+	railed.reported = true
+	rt.pending = append(rt.pending, SliderRailedEvent{
+		SliderID: sliderIdx,
+		RawValue: rawValue,
+		Duration: now.Sub(railed.since),
+	})
+}
+
+// take returns and clears the railed slider events queued up so far
+func (rt *railTracker) take() []SliderRailedEvent {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	pending := rt.pending
+	rt.pending = nil
+
+	return pending
+}
@@ -0,0 +1,97 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRailedSliders(t *testing.T) {
+	const railWarningDelay = 30 * time.Millisecond
+
+	tests := []struct {
+		name             string
+		railWarningDelay time.Duration
+		lines            []string
+		want             []railedSliderResult
+	}{
+		{
+			name:             "stuck at the top",
+			railWarningDelay: railWarningDelay,
+			lines:            []string{"1023|512\r\n", "1023|600\r\n"},
+			want:             []railedSliderResult{{SliderID: 0, RawValue: 1023}},
+		},
+		{
+			name:             "stuck at the bottom",
+			railWarningDelay: railWarningDelay,
+			lines:            []string{"512|0\r\n"},
+			want:             []railedSliderResult{{SliderID: 1, RawValue: 0}},
+		},
+		{
+			name:             "leaving the extreme now and then",
+			railWarningDelay: railWarningDelay,
+			lines:            []string{"1023\r\n", "1023\r\n", "1000\r\n"},
+			want:             []railedSliderResult{},
+		},
+		{
+			name:             "jumping between extremes",
+			railWarningDelay: railWarningDelay,
+			lines:            []string{"1023\r\n", "0\r\n"},
+			want:             []railedSliderResult{},
+		},
+		{
+			name:  "no rail warning delay",
+			lines: []string{"1023\r\n"},
+			want:  []railedSliderResult{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{RailWarningDelay: test.railWarningDelay})
+			sio.deej.notifier = &testNotifier{}
+
+			events := sio.SubscribeToSliderMoveEvents()
+			railedEvents := sio.SubscribeToRailedSliders()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			// keep repeating the lines for several times the delay, as a board would
+			done := make(chan bool)
+			go func() {
+				for i := 0; i < 8; i++ {
+					sio.handleLine(sio.logger, test.lines[i%len(test.lines)], format)
+					time.Sleep(railWarningDelay / 3)
+				}
+
+				close(done)
+			}()
+
+			// both are delivered on unbuffered channels, so they've all been received once the lines are handled
+			got := []railedSliderResult{}
+			for handled := false; !handled; {
+				select {
+				case <-events:
+				case event := <-railedEvents:
+					got = append(got, railedSliderResult{SliderID: event.SliderID, RawValue: event.RawValue})
+
+					if event.Duration < test.railWarningDelay {
+						t.Errorf("reported slider %d after %v, want at least %v", event.SliderID, event.Duration,
+							test.railWarningDelay)
+					}
+				case <-done:
+					handled = true
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got railed sliders %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// railedSliderResult is the part of a SliderRailedEvent that doesn't depend on timing
+type railedSliderResult struct {
+	SliderID int
+	RawValue int
+}
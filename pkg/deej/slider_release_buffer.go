@@ -0,0 +1,81 @@
+package deej
+
+import (
+	"sync"
+	"time"
+)
+
+// sliderReleaseBuffer holds back each slider's moves until it's been left alone for a while, approximating
+// the moment it's let go of (there's no touch sensing). only the value it was left at is released
+type sliderReleaseBuffer struct {
+	pending map[int]*pendingRelease
+
+	// incremented for every move, so a timer that's been superseded knows not to release anything
+	sequence uint64
+
+	lock sync.Locker
+}
+
+type pendingRelease struct {
+	moveEvent SliderMoveEvent
+	sequence  uint64
+	timer     *time.Timer
+}
+
+func newSliderReleaseBuffer() *sliderReleaseBuffer {
+	return &sliderReleaseBuffer{
+		pending: make(map[int]*pendingRelease),
+		lock:    &sync.Mutex{},
+	}
+}
+
+// put stores the given event as its slider's latest, and (re)starts the wait for that slider to be released.
+// once it's been quiet for the given window, release is called with the latest event
+func (rb *sliderReleaseBuffer) put(moveEvent SliderMoveEvent, window time.Duration, release func(SliderMoveEvent)) {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	if previous, ok := rb.pending[moveEvent.SliderID]; ok {
+		previous.timer.Stop()
+	}
+
+	rb.sequence++
+	sequence := rb.sequence
+
+	rb.pending[moveEvent.SliderID] = &pendingRelease{
+		moveEvent: moveEvent,
+		sequence:  sequence,
+		timer: time.AfterFunc(window, func() {
+			if releasedEvent, ok := rb.take(moveEvent.SliderID, sequence); ok {
+				release(releasedEvent)
+			}
+		}),
+	}
+}
+
+// take removes and returns the given slider's pending event, unless it's been superseded by a later move
+func (rb *sliderReleaseBuffer) take(sliderID int, sequence uint64) (SliderMoveEvent, bool) {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	pending, ok := rb.pending[sliderID]
+	if !ok || pending.sequence != sequence {
+		return SliderMoveEvent{}, false
+	}
+
+	delete(rb.pending, sliderID)
+
+	return pending.moveEvent, true
+}
+
+// reset drops all pending events without releasing them
+func (rb *sliderReleaseBuffer) reset() {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	for _, pending := range rb.pending {
+		pending.timer.Stop()
+	}
+
+	rb.pending = make(map[int]*pendingRelease)
+}
@@ -0,0 +1,57 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyOnRelease(t *testing.T) {
+	const window = 50 * time.Millisecond
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		want   []SliderMoveEvent
+	}{
+		{
+			name: "applied right away",
+			want: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.5},
+				{SliderID: 0, PercentValue: 0.75},
+				{SliderID: 0, PercentValue: 1},
+			},
+		},
+		{
+			name:   "applied once let go",
+			window: window,
+			want: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.5, Preview: true},
+				{SliderID: 0, PercentValue: 0.75, Preview: true},
+				{SliderID: 0, PercentValue: 1, Preview: true},
+				{SliderID: 0, PercentValue: 1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{ApplyOnRelease: test.window})
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			got := collectTestEvents(events, func() {
+				for _, line := range []string{"512\r\n", "768\r\n", "1023\r\n"} {
+					sio.handleLine(sio.logger, line, format)
+				}
+
+				// give the slider a few windows to count as let go
+				time.Sleep(3 * window)
+			})
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got events %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
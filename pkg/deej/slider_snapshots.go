@@ -0,0 +1,63 @@
+package deej
+
+import (
+	"time"
+)
+
+// SliderSnapshot holds the values of all sliders at a point in time. Sliders that
+// haven't reported a value since the last (re)connection are -1
+type SliderSnapshot struct {
+	Values    []float32
+	Timestamp time.Time
+}
+
+// SubscribeToSnapshots returns an unbuffered channel that receives a snapshot of all slider values
+// once per interval, for consumers that don't need every individual move. Intervals in which nothing
+// changed since the last snapshot are skipped, so an idle board produces no snapshots at all
+func (sio *SerialIO) SubscribeToSnapshots(interval time.Duration) chan SliderSnapshot {
+	ch := make(chan SliderSnapshot)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var lastValues []float32
+
+		for range ticker.C {
+			values := sio.snapshotValues()
+			if valuesEqual(values, lastValues) {
+				continue
+			}
+
+			lastValues = values
+			ch <- SliderSnapshot{Values: values, Timestamp: time.Now()}
+		}
+	}()
+
+	return ch
+}
+
+// snapshotValues returns a copy of the current slider values
+func (sio *SerialIO) snapshotValues() []float32 {
+	sio.valuesLock.Lock()
+	defer sio.valuesLock.Unlock()
+
+	values := make([]float32, len(sio.currentSliderPercentValues))
+	copy(values, sio.currentSliderPercentValues)
+
+	return values
+}
+
+func valuesEqual(a []float32, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshots(t *testing.T) {
+	const interval = 10 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  [][]float32
+	}{
+		{
+			name: "idle board",
+			want: [][]float32{},
+		},
+		{
+			name:  "moves, then idle",
+			lines: []string{"0|1023\r\n", "512|1023\r\n"},
+			want:  [][]float32{{0.5, 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{})
+			format := newLineFormat("|", "\n", checksumNone)
+
+			for _, line := range test.lines {
+				sio.handleLine(sio.logger, line, format)
+			}
+
+			snapshots := sio.SubscribeToSnapshots(interval)
+
+			// plenty of intervals for an idle board to (wrongly) produce snapshots in
+			got := [][]float32{}
+			timeout := time.After(20 * interval)
+
+			for collecting := true; collecting; {
+				select {
+				case snapshot := <-snapshots:
+					got = append(got, snapshot.Values)
+				case <-timeout:
+					collecting = false
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got snapshots %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package deej
+
+import (
+	"sync"
+)
+
+// sliderTickBuffer holds back slider moves until the next tick, keeping only the latest value of each slider.
+// unlike a sliderMailbox, which only coalesces when a consumer falls behind, this coalesces for everyone,
+// so whipping a slider results in at most one move per tick no matter how fast the consumers are
+type sliderTickBuffer struct {
+	pending map[int]SliderMoveEvent
+	order   []int
+
+	// whether there's a goroutine flushing this buffer
+	flushing bool
+
+	lock sync.Locker
+}
+
+func newSliderTickBuffer() *sliderTickBuffer {
+	return &sliderTickBuffer{
+		pending: make(map[int]SliderMoveEvent),
+		lock:    &sync.Mutex{},
+	}
+}
+
+// put stores the given events, and returns true if the caller should start flushing the buffer
+func (tb *sliderTickBuffer) put(moveEvents []SliderMoveEvent) bool {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	for _, moveEvent := range moveEvents {
+		if _, ok := tb.pending[moveEvent.SliderID]; !ok {
+			tb.order = append(tb.order, moveEvent.SliderID)
+		}
+
+		tb.pending[moveEvent.SliderID] = moveEvent
+	}
+
+	if tb.flushing || len(tb.pending) == 0 {
+		return false
+	}
+
+	tb.flushing = true
+	return true
+}
+
+// take empties the buffer and returns its events in the order their sliders first moved. once the buffer
+// is found empty, it's considered no longer flushing and the caller should stop
+func (tb *sliderTickBuffer) take() []SliderMoveEvent {
+	tb.lock.Lock()
+	defer tb.lock.Unlock()
+
+	if len(tb.pending) == 0 {
+		tb.flushing = false
+		return nil
+	}
+
+	moveEvents := make([]SliderMoveEvent, 0, len(tb.order))
+	for _, sliderID := range tb.order {
+		moveEvents = append(moveEvents, tb.pending[sliderID])
+	}
+
+	tb.pending = make(map[int]SliderMoveEvent)
+	tb.order = nil
+
+	return moveEvents
+}
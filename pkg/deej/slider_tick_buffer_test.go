@@ -0,0 +1,52 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSliderEventTick(t *testing.T) {
+	const tick = 50 * time.Millisecond
+
+	tests := []struct {
+		name string
+		tick time.Duration
+		want []SliderMoveEvent
+	}{
+		{
+			name: "no tick",
+			want: []SliderMoveEvent{
+				{SliderID: 0, PercentValue: 0.5},
+				{SliderID: 0, PercentValue: 0.75},
+				{SliderID: 0, PercentValue: 1},
+			},
+		},
+		{
+			name: "burst within a tick",
+			tick: tick,
+			want: []SliderMoveEvent{{SliderID: 0, PercentValue: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sio := newTestSerialIO(t, &CanonicalConfig{SliderEventTick: test.tick})
+			events := sio.SubscribeToSliderMoveEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			got := collectTestEvents(events, func() {
+				for _, line := range []string{"512\r\n", "768\r\n", "1023\r\n"} {
+					sio.handleLine(sio.logger, line, format)
+				}
+
+				// give held back moves a few ticks to go out
+				time.Sleep(3 * tick)
+			})
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got events %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package deej
+
+import (
+	"sync"
+)
+
+// SliderTrigger fires whenever its slider crosses the threshold in the given direction
+type SliderTrigger struct {
+	Threshold float32
+	Rising    bool
+}
+
+// SliderThresholdEvent represents a slider crossing one of its configured triggers
+type SliderThresholdEvent struct {
+	SliderID     int
+	Threshold    float32
+	Rising       bool
+	PercentValue float32
+}
+
+// trigger directions, as they appear in the config file (i.e. "rising 0.8")
+const (
+	triggerDirectionRising  = "rising"
+	triggerDirectionFalling = "falling"
+)
+
+// triggerTracker remembers which triggers are armed, so a slider resting near a threshold doesn't
+// keep firing it - once fired, a trigger only re-arms after moving back past its threshold by the
+// configured hysteresis
+type triggerTracker struct {
+	armed map[int][]bool
+	lock  sync.Locker
+}
+
+func newTriggerTracker() *triggerTracker {
+	return &triggerTracker{
+		armed: make(map[int][]bool),
+		lock:  &sync.Mutex{},
+	}
+}
+
+// reset disarms all triggers until their sliders report again
+func (tt *triggerTracker) reset() {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+
+	tt.armed = make(map[int][]bool)
+}
+
+// evaluate returns the threshold events caused by the given move events
+func (tt *triggerTracker) evaluate(
+	triggers map[int][]SliderTrigger,
+	hysteresis float32,
+	moveEvents []SliderMoveEvent,
+) []SliderThresholdEvent {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+
+	thresholdEvents := []SliderThresholdEvent{}
+
+	for _, moveEvent := range moveEvents {
+		if moveEvent.Preview {
+			continue
+		}
+
+		sliderTriggers, ok := triggers[moveEvent.SliderID]
+		if !ok {
+			continue
+		}
+
+		value := moveEvent.PercentValue
+		armed, ok := tt.armed[moveEvent.SliderID]
+
+		// the first value we see for a slider (or a changed set of triggers) only determines which triggers are armed,
+		// so that a slider that's already past its threshold doesn't fire on connect
+		if !ok || len(armed) != len(sliderTriggers) {
+			armed = make([]bool, len(sliderTriggers))
+			for idx, trigger := range sliderTriggers {
+				armed[idx] = trigger.Rising && value < trigger.Threshold ||
+					!trigger.Rising && value > trigger.Threshold
+			}
+
+			tt.armed[moveEvent.SliderID] = armed
+			continue
+		}
+
+		for idx, trigger := range sliderTriggers {
+			crossed := trigger.Rising && value >= trigger.Threshold ||
+				!trigger.Rising && value <= trigger.Threshold
+
+			if armed[idx] && crossed {
+				armed[idx] = false
+
+				thresholdEvents = append(thresholdEvents, SliderThresholdEvent{
+					SliderID:     moveEvent.SliderID,
+					Threshold:    trigger.Threshold,
+					Rising:       trigger.Rising,
+					PercentValue: value,
+				})
+
+				continue
+			}
+
+			rearmed := trigger.Rising && value <= trigger.Threshold-hysteresis ||
+				!trigger.Rising && value >= trigger.Threshold+hysteresis
+
+			if !armed[idx] && rearmed {
+				armed[idx] = true
+			}
+		}
+	}
+
+	return thresholdEvents
+}
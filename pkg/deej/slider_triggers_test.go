@@ -0,0 +1,70 @@
+package deej
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSliderTriggers(t *testing.T) {
+	rising := map[int][]SliderTrigger{0: {{Threshold: 0.8, Rising: true}}}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []SliderThresholdEvent
+	}{
+		{
+			name:  "rising past the threshold fires once",
+			lines: []string{"512\r\n", "870\r\n", "921\r\n", "839\r\n", "921\r\n"},
+			want:  []SliderThresholdEvent{{SliderID: 0, Threshold: 0.8, Rising: true, PercentValue: 0.85}},
+		},
+		{
+			name:  "moving back past the hysteresis re-arms",
+			lines: []string{"512\r\n", "870\r\n", "716\r\n", "870\r\n"},
+			want: []SliderThresholdEvent{
+				{SliderID: 0, Threshold: 0.8, Rising: true, PercentValue: 0.85},
+				{SliderID: 0, Threshold: 0.8, Rising: true, PercentValue: 0.85},
+			},
+		},
+		{
+			name:  "already past the threshold on connect",
+			lines: []string{"921\r\n", "1023\r\n"},
+			want:  []SliderThresholdEvent{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := CanonicalConfig{SliderTriggers: rising, TriggerHysteresis: defaultTriggerHysteresis}
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+			thresholdEvents := sio.SubscribeToThresholdEvents()
+			format := newLineFormat("|", "\n", checksumNone)
+
+			done := make(chan bool)
+			go func() {
+				for _, line := range test.lines {
+					sio.handleLine(sio.logger, line, format)
+				}
+
+				close(done)
+			}()
+
+			// both are delivered on unbuffered channels, so they've all been received once the lines are handled
+			got := []SliderThresholdEvent{}
+			for handled := false; !handled; {
+				select {
+				case <-events:
+				case event := <-thresholdEvents:
+					got = append(got, event)
+				case <-done:
+					handled = true
+				}
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got threshold events %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
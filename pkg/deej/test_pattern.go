@@ -0,0 +1,119 @@
+package deej
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/omriharel/deej/pkg/deej/util"
+)
+
+// test patterns synthesize slider move events without any hardware attached,
+// which is useful when working on anything that consumes them
+const (
+	testPatternSine   = "sine"
+	testPatternSweep  = "sweep"
+	testPatternRandom = "random"
+
+	// how many steps it takes a pattern to complete a full cycle
+	testPatternPeriod = 100
+)
+
+// StartTestPattern starts emitting synthetic slider move events for all mapped sliders,
+// bypassing the serial connection entirely. Supported patterns are "sine", "sweep" and "random"
+func (sio *SerialIO) StartTestPattern(pattern string) error {
+	var generate func(sliderIdx int, step int) float32
+
+	switch pattern {
+	case testPatternSine:
+		generate = func(sliderIdx int, step int) float32 {
+
+			// offset each slider a bit so they don't all move in unison
+			phase := 2 * math.Pi * float64(step+sliderIdx*testPatternPeriod/10) / testPatternPeriod
+			return float32(0.5 + 0.5*math.Sin(phase))
+		}
+	case testPatternSweep:
+		generate = func(sliderIdx int, step int) float32 {
+
+			// go all the way up, then all the way down
+			position := step % (2 * testPatternPeriod)
+			if position > testPatternPeriod {
+				position = 2*testPatternPeriod - position
+			}
+
+			return float32(position) / testPatternPeriod
+		}
+	case testPatternRandom:
+		generate = func(sliderIdx int, step int) float32 {
+			return rand.Float32()
+		}
+	default:
+		return fmt.Errorf("unknown test pattern: %s", pattern)
+	}
+
+	if sio.testPatternStopChannel != nil {
+		sio.logger.Warn("Test pattern already running, can't start another without stopping first")
+		return errors.New("serial: test pattern already active")
+	}
+
+	// generate values for every slider up to the highest mapped one
+	numSliders := 0
+	sio.deej.config.SliderMapping.iterate(func(sliderIdx int, _ []string) {
+		if sliderIdx+1 > numSliders {
+			numSliders = sliderIdx + 1
+		}
+	})
+
+	if numSliders == 0 {
+		return errors.New("serial: no sliders mapped, can't start test pattern")
+	}
+
+	interval := time.Second / time.Duration(sio.deej.config.TestPatternRate)
+
+	sio.logger.Infow("Starting test pattern",
+		"pattern", pattern,
+		"sliders", numSliders,
+		"interval", interval)
+
+	stopChannel := make(chan bool)
+	sio.testPatternStopChannel = stopChannel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for step := 0; ; step++ {
+			select {
+			case <-stopChannel:
+				sio.logger.Debug("Test pattern stopped")
+				return
+			case <-ticker.C:
+				moveEvents := make([]SliderMoveEvent, numSliders)
+
+				for sliderIdx := range moveEvents {
+					moveEvents[sliderIdx] = SliderMoveEvent{
+						SliderID:     sliderIdx,
+						PercentValue: util.NormalizeScalar(generate(sliderIdx, step)),
+					}
+				}
+
+				sio.deliverMoveEvents(moveEvents)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopTestPattern stops emitting synthetic slider move events, if a test pattern is running
+func (sio *SerialIO) StopTestPattern() {
+	if sio.testPatternStopChannel == nil {
+		sio.logger.Debug("No test pattern running, nothing to stop")
+		return
+	}
+
+	sio.testPatternStopChannel <- true
+	sio.testPatternStopChannel = nil
+}
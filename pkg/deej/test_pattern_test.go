@@ -0,0 +1,61 @@
+package deej
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTestPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "sweep", pattern: testPatternSweep},
+		{name: "unknown pattern", pattern: "zigzag", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := CanonicalConfig{TestPatternRate: 1000}
+			config.SliderMapping = newSliderMap()
+			config.SliderMapping.set(0, []string{"master"})
+
+			sio := newTestSerialIO(t, &config)
+			events := sio.SubscribeToSliderMoveEvents()
+
+			err := sio.StartTestPattern(test.pattern)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("StartTestPattern(%q) error = %v, wantErr %v", test.pattern, err, test.wantErr)
+			}
+
+			if test.wantErr {
+				return
+			}
+
+			// a sweep goes all the way up before it comes back down
+			values := []float32{}
+			for len(values) <= testPatternPeriod {
+				select {
+				case event := <-events:
+					values = append(values, event.PercentValue)
+				case <-time.After(time.Second):
+					t.Fatalf("got %d values, want %d", len(values), testPatternPeriod+1)
+				}
+			}
+
+			// keep draining while stopping, since the pattern may be waiting on us
+			collectTestEvents(events, sio.StopTestPattern)
+
+			if values[0] != 0 || values[testPatternPeriod] != 1 {
+				t.Errorf("got sweep from %v to %v, want from 0 to 1", values[0], values[testPatternPeriod])
+			}
+
+			for i := 1; i < len(values); i++ {
+				if values[i] < values[i-1] {
+					t.Fatalf("got value %v after %v at step %d, want a rising sweep", values[i], values[i-1], i)
+				}
+			}
+		})
+	}
+}
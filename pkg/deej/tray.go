@@ -23,6 +23,8 @@ func (d *Deej) initializeTray(onDone func()) {
 		refreshSessions := systray.AddMenuItem("Re-scan audio sessions", "Manually refresh audio sessions if something's stuck")
 		refreshSessions.SetIcon(icon.RefreshSessions)
 
+		resumeConnection := systray.AddMenuItem("Reconnect to board", "Reopen the serial connection after an idle disconnect")
+
 		if d.version != "" {
 			systray.AddSeparator()
 			versionInfo := systray.AddMenuItem(d.version, "")
@@ -63,6 +65,14 @@ func (d *Deej) initializeTray(onDone func()) {
 					// performance: the reason that forcing a refresh here is okay is that users can't spam the
 					// right-click -> select-this-option sequence at a rate that's meaningful to performance
 					d.sessions.refreshSessions(true)
+
+				// resume serial connection
+				case <-resumeConnection.ClickedCh:
+					logger.Info("Reconnect menu item clicked, resuming serial connection")
+
+					if err := d.serial.Resume(); err != nil {
+						logger.Warnw("Failed to resume serial connection", "error", err)
+					}
 				}
 			}
 		}()
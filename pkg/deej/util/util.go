@@ -107,7 +107,12 @@ func SignificantlyDifferent(old float32, new float32, noiseReductionLevel string
 		break
 	}
 
-	if math.Abs(float64(old-new)) >= significantDifferenceThreshold {
+	return SignificantlyDifferentBy(old, new, significantDifferenceThreshold)
+}
+
+// SignificantlyDifferentBy is like SignificantlyDifferent, but takes an explicit threshold instead of a noise reduction level
+func SignificantlyDifferentBy(old float32, new float32, threshold float64) bool {
+	if math.Abs(float64(old-new)) >= threshold {
 		return true
 	}
 
@@ -120,6 +125,42 @@ func SignificantlyDifferent(old float32, new float32, noiseReductionLevel string
 	return false
 }
 
+// SmoothScalar applies an exponential moving average to the given value, where factor (0.0 - 1.0) is the weight
+// given to the previous value. the smoothing is progressively bypassed as the change between the two values
+// approaches bypassThreshold, so that small jitter is smoothed while deliberate, large moves track immediately
+func SmoothScalar(previous float32, current float32, factor float32, bypassThreshold float32) float32 {
+	effectiveFactor := factor
+
+	if bypassThreshold > 0 {
+		delta := float32(math.Abs(float64(current - previous)))
+		if delta >= bypassThreshold {
+			return current
+		}
+
+		effectiveFactor *= 1 - delta/bypassThreshold
+	}
+
+	return effectiveFactor*previous + (1-effectiveFactor)*current
+}
+
+// QuantizeScalar snaps the given value (0.0 - 1.0) to the nearest of the given number of evenly spaced steps,
+// rounded to 2 points of precision. to keep it from chattering between two steps when it's sitting right between
+// them, it only leaves the previous value's step once it's past the boundary by hysteresis (a fraction of a step)
+func QuantizeScalar(v float32, previous float32, steps int, hysteresis float32) float32 {
+	intervals := float64(steps - 1)
+	position := float64(v) * intervals
+	step := math.Round(position)
+
+	if previous >= 0 {
+		previousStep := math.Round(float64(previous) * intervals)
+		if math.Abs(position-previousStep) < 0.5+float64(hysteresis) {
+			step = previousStep
+		}
+	}
+
+	return float32(math.Round(step/intervals*100) / 100.0)
+}
+
 // a helper to make sure volume snaps correctly to 0 and 100, where appropriate
 func almostEquals(a float32, b float32) bool {
 	return math.Abs(float64(a-b)) < 0.000001